@@ -2,20 +2,29 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
 type (
 	Config struct {
-		JWT     JWT
-		App     App
-		PG      PG
-		Log     Log
-		HTTP    HTTP
-		Swagger Swagger
-		Cache   Cache
-		Redis   Redis
+		JWT           JWT
+		App           App
+		PG            PG
+		Log           Log
+		HTTP          HTTP
+		Swagger       Swagger
+		Cache         Cache
+		Redis         Redis
+		Memcached     Memcached
+		RBAC          RBAC
+		Registration  Registration
+		Login         Login
+		RateLimit     RateLimit
+		Password      Password
+		PasswordReset PasswordReset
+		Notifier      Notifier
 	}
 
 	App struct {
@@ -42,14 +51,43 @@ type (
 	}
 
 	JWT struct {
-		Secret           string `env:"JWT_SECRET" envDefault:"your-super-secret-jwt-key-change-in-production"`
-		SigningAlgorithm string `env:"JWT_SIGNING_ALGORITHM" envDefault:"HS256"`
-		AccessTokenTTL   int    `env:"JWT_ACCESS_TOKEN_TTL" envDefault:"900"`
-		RefreshTokenTTL  int    `env:"JWT_REFRESH_TOKEN_TTL" envDefault:"604800"`
+		Secret string `env:"JWT_SECRET" envDefault:"your-super-secret-jwt-key-change-in-production"`
+		// SigningAlgorithm is HS256, RS256, ES256 or EdDSA. The asymmetric ones
+		// sign with PrivateKeyFile and also accept tokens signed by the
+		// PublicKeyFiles of previous keys.
+		SigningAlgorithm string   `env:"JWT_SIGNING_ALGORITHM" envDefault:"HS256"`
+		PrivateKeyFile   string   `env:"JWT_PRIVATE_KEY_FILE" envDefault:""`
+		PublicKeyFiles   []string `env:"JWT_PUBLIC_KEY_FILES" envSeparator:","`
+		AccessTokenTTL   int      `env:"JWT_ACCESS_TOKEN_TTL" envDefault:"900"`
+		RefreshTokenTTL  int      `env:"JWT_REFRESH_TOKEN_TTL" envDefault:"604800"`
+		// RefreshSecret signs refresh tokens, empty means Secret is used
+		RefreshSecret   string `env:"JWT_REFRESH_SECRET" envDefault:""`
+		AccessAudience  string `env:"JWT_ACCESS_AUDIENCE" envDefault:"spy-cats-api"`
+		RefreshAudience string `env:"JWT_REFRESH_AUDIENCE" envDefault:"spy-cats-api/refresh"`
 	}
 
 	Cache struct {
 		Type string `env:"CACHE_TYPE" envDefault:"redis"`
+		// CatTTL and MissionTTL control read-through caching of lookups, 0 disables it
+		CatTTL     time.Duration `env:"CACHE_CAT_TTL" envDefault:"5m"`
+		MissionTTL time.Duration `env:"CACHE_MISSION_TTL" envDefault:"1m"`
+		// Settings of the "tiered" cache type (in-memory L1 in front of Redis)
+		LocalTTL            time.Duration `env:"CACHE_LOCAL_TTL" envDefault:"30s"`
+		NegativeTTL         time.Duration `env:"CACHE_NEGATIVE_TTL" envDefault:"5s"`
+		InvalidationChannel string        `env:"CACHE_INVALIDATION_CHANNEL" envDefault:"cache:invalidate"`
+		// Bounds of the "memory" cache type and of the tiered L1, 0 means no limit
+		MemoryMaxEntries int    `env:"CACHE_MEMORY_MAX_ENTRIES" envDefault:"100000"`
+		MemoryMaxBytes   int64  `env:"CACHE_MEMORY_MAX_BYTES" envDefault:"67108864"`
+		MemoryEviction   string `env:"CACHE_MEMORY_EVICTION" envDefault:"lru"`
+		MemoryShards     int    `env:"CACHE_MEMORY_SHARDS" envDefault:"16"`
+		// Security keys (revocations, sessions, one-time codes, login counters)
+		// are never evicted from the "memory" cache and have bounds of their
+		// own. Once these are reached storing them fails until some expire.
+		MemoryMaxPinnedEntries int   `env:"CACHE_MEMORY_MAX_PINNED_ENTRIES" envDefault:"500000"`
+		MemoryMaxPinnedBytes   int64 `env:"CACHE_MEMORY_MAX_PINNED_BYTES" envDefault:"134217728"`
+		// SnapshotPath persists the "memory" cache across restarts, empty disables it
+		SnapshotPath     string        `env:"CACHE_SNAPSHOT_PATH" envDefault:""`
+		SnapshotInterval time.Duration `env:"CACHE_SNAPSHOT_INTERVAL" envDefault:"1m"`
 	}
 
 	Redis struct {
@@ -57,6 +95,88 @@ type (
 		Password string `env:"REDIS_PASSWORD" envDefault:""`
 		DB       int    `env:"REDIS_DB" envDefault:"0"`
 	}
+
+	RBAC struct {
+		// Roles maps role names to comma separated permissions, e.g.
+		// "admin=*;agent=targets:notes". Empty uses the built-in admin,
+		// manager and agent roles.
+		Roles map[string]string `env:"RBAC_ROLES" envSeparator:";" envKeyValSeparator:"="`
+		// DefaultRole is given to every self-registered user. Users holding the
+		// former roles "user" or "spy" get its permissions unless those are
+		// configured.
+		DefaultRole string `env:"RBAC_DEFAULT_ROLE" envDefault:"agent"`
+	}
+
+	Registration struct {
+		// Mode is "open", or "invite" to require a code issued by an admin
+		Mode      string        `env:"REGISTRATION_MODE" envDefault:"open"`
+		InviteTTL time.Duration `env:"REGISTRATION_INVITE_TTL" envDefault:"72h"`
+	}
+
+	Login struct {
+		// Failed logins per username and per client IP before every further
+		// attempt is delayed by BackoffBase, doubling up to BackoffMax
+		FreeAttempts   int           `env:"LOGIN_FREE_ATTEMPTS" envDefault:"3"`
+		IPFreeAttempts int           `env:"LOGIN_IP_FREE_ATTEMPTS" envDefault:"20"`
+		BackoffBase    time.Duration `env:"LOGIN_BACKOFF_BASE" envDefault:"1s"`
+		BackoffMax     time.Duration `env:"LOGIN_BACKOFF_MAX" envDefault:"5m"`
+		// Failed logins that lock the username or IP out for LockoutDuration
+		UserLockoutAttempts int           `env:"LOGIN_USER_LOCKOUT_ATTEMPTS" envDefault:"10"`
+		IPLockoutAttempts   int           `env:"LOGIN_IP_LOCKOUT_ATTEMPTS" envDefault:"100"`
+		LockoutDuration     time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+		// Window is how long failures are remembered after the last one
+		Window time.Duration `env:"LOGIN_ATTEMPT_WINDOW" envDefault:"15m"`
+	}
+
+	RateLimit struct {
+		Enabled bool `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+		// Requests per Window across the API from one client IP and from one
+		// authenticated user, 0 disables the limit
+		IPRequests   int           `env:"RATE_LIMIT_IP_REQUESTS" envDefault:"300"`
+		UserRequests int           `env:"RATE_LIMIT_USER_REQUESTS" envDefault:"120"`
+		Window       time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"1m"`
+		// Requests per AuthWindow to each of register, login, refresh, forgot
+		// password and reset password from one client IP
+		AuthRequests int           `env:"RATE_LIMIT_AUTH_REQUESTS" envDefault:"20"`
+		AuthWindow   time.Duration `env:"RATE_LIMIT_AUTH_WINDOW" envDefault:"1m"`
+	}
+
+	Password struct {
+		MinLength int `env:"PASSWORD_MIN_LENGTH" envDefault:"10"`
+		// MinClasses is how many of lowercase letters, uppercase letters,
+		// digits and symbols a password has to mix
+		MinClasses int `env:"PASSWORD_MIN_CLASSES" envDefault:"2"`
+		// RejectCommon checks passwords against the bundled list of common
+		// passwords
+		RejectCommon bool `env:"PASSWORD_REJECT_COMMON" envDefault:"true"`
+		// BcryptCost of new hashes, stored hashes with another cost are
+		// upgraded when their users log in
+		BcryptCost int `env:"PASSWORD_BCRYPT_COST" envDefault:"12"`
+	}
+
+	PasswordReset struct {
+		TokenTTL time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"30m"`
+		// URL of the page that completes the reset, the token is added as
+		// the token query parameter
+		URL string `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:8080/reset-password"`
+	}
+
+	Notifier struct {
+		// Type is "log", or "file" to append notifications to FilePath as
+		// JSON lines. Both are meant for local development.
+		Type     string `env:"NOTIFIER_TYPE" envDefault:"log"`
+		FilePath string `env:"NOTIFIER_FILE_PATH" envDefault:"notifications.log"`
+		// Workers send queued notifications in the background. At most
+		// QueueSize wait, requests that would queue more are answered with 503.
+		QueueSize int `env:"NOTIFIER_QUEUE_SIZE" envDefault:"100"`
+		Workers   int `env:"NOTIFIER_WORKERS" envDefault:"2"`
+	}
+
+	Memcached struct {
+		Servers      []string      `env:"MEMCACHED_SERVERS" envSeparator:"," envDefault:"localhost:11211"`
+		Timeout      time.Duration `env:"MEMCACHED_TIMEOUT" envDefault:"500ms"`
+		MaxIdleConns int           `env:"MEMCACHED_MAX_IDLE_CONNS" envDefault:"10"`
+	}
 )
 
 // NewConfig returns app config.
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"DevelopsToday/config"
 	"DevelopsToday/internal/controller/http"
@@ -19,11 +21,17 @@ import (
 	gormlog "gorm.io/gorm/logger"
 )
 
+// tasksShutdownTimeout bounds how long shutdown waits for queued tasks
+const tasksShutdownTimeout = 10 * time.Second
+
 // App represents the application
 type App struct {
 	Handler *gin.Engine
 	Server  *server.Server
 	Logger  *logger.Logger
+	// Tasks runs background work such as password reset emails, closing it
+	// waits for the queued tasks
+	Tasks *services.TaskQueue
 }
 
 // New creates a new application instance for testing
@@ -55,19 +63,53 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("failed to create cache service: %w", err)
 	}
 
+	// Domain events
+	events := services.NewEventBus()
+
 	// JWT Service
-	jwtService := services.NewJWTService(cfg, cacheService)
+	jwtService, err := services.NewJWTService(cfg, cacheService, events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT service: %w", err)
+	}
+
+	// Role definitions
+	rbac, err := services.NewRBAC(cfg.RBAC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	// Registration policy
+	invitations, err := services.NewInvitationService(cfg.Registration, cacheService, rbac)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation service: %w", err)
+	}
+
+	// Outbound notifications
+	notifier, err := services.NewNotifier(cfg.Notifier, l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+
+	// Password policy
+	passwordPolicy, err := services.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password policy: %w", err)
+	}
+
+	// Background notifications
+	tasks := services.NewTaskQueue(cfg.Notifier.QueueSize, cfg.Notifier.Workers)
 
 	httpServer := server.New(
 		server.Port(cfg.HTTP.Port),
 	)
 
-	http.NewV1Controller(httpServer.Engine, store, cfg, l, jwtService)
+	http.NewV1Controller(httpServer.Engine, store, cfg, l, jwtService, cacheService, events, rbac, invitations, notifier, passwordPolicy, tasks)
 
 	return &App{
 		Handler: httpServer.Engine,
 		Server:  httpServer,
 		Logger:  l,
+		Tasks:   tasks,
 	}, nil
 }
 
@@ -110,16 +152,56 @@ func Run(cfg *config.Config) {
 	}
 	l.Info("Cache service created and connected")
 
+	stopCacheSnapshots := startCacheSnapshots(cacheService, cfg, l)
+
+	// Domain events
+	events := services.NewEventBus()
+
 	// JWT Service
-	jwtService := services.NewJWTService(cfg, cacheService)
+	jwtService, err := services.NewJWTService(cfg, cacheService, events)
+	if err != nil {
+		l.Error("Failed to create JWT service: %v", err)
+		panic(err)
+	}
 	l.Info("JWT service initialized")
 
+	// Role definitions
+	rbac, err := services.NewRBAC(cfg.RBAC)
+	if err != nil {
+		l.Error("Failed to load roles: %v", err)
+		panic(err)
+	}
+
+	// Registration policy
+	invitations, err := services.NewInvitationService(cfg.Registration, cacheService, rbac)
+	if err != nil {
+		l.Error("Failed to create invitation service: %v", err)
+		panic(err)
+	}
+
+	// Outbound notifications
+	notifier, err := services.NewNotifier(cfg.Notifier, l)
+	if err != nil {
+		l.Error("Failed to create notifier: %v", err)
+		panic(err)
+	}
+
+	// Password policy
+	passwordPolicy, err := services.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		l.Error("Failed to create password policy: %v", err)
+		panic(err)
+	}
+
+	// Background notifications
+	tasks := services.NewTaskQueue(cfg.Notifier.QueueSize, cfg.Notifier.Workers)
+
 	httpServer := server.New(
 		server.Port(cfg.HTTP.Port),
 	)
 	l.Info("HTTP server created on port: %s", cfg.HTTP.Port)
 
-	http.NewV1Controller(httpServer.Engine, store, cfg, l, jwtService)
+	http.NewV1Controller(httpServer.Engine, store, cfg, l, jwtService, cacheService, events, rbac, invitations, notifier, passwordPolicy, tasks)
 	l.Info("Controllers initialized")
 
 	httpServer.Start()
@@ -139,4 +221,21 @@ func Run(cfg *config.Config) {
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
+
+	// No more requests queue tasks, send the queued ones while the cache and
+	// the database are still open
+	ctx, cancel := context.WithTimeout(context.Background(), tasksShutdownTimeout)
+	err = tasks.Close(ctx)
+	cancel()
+	if err != nil {
+		l.Error(fmt.Errorf("app - Run - tasks.Close: %w", err))
+	}
+
+	// Requests are drained, so the final snapshot holds every revocation
+	stopCacheSnapshots()
+
+	err = cacheService.Close()
+	if err != nil {
+		l.Error(fmt.Errorf("app - Run - cacheService.Close: %w", err))
+	}
 }
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/services"
+	"DevelopsToday/pkg/logger"
+)
+
+// startCacheSnapshots periodically saves the in-memory cache to
+// cfg.Cache.SnapshotPath. The returned function stops the snapshots and saves
+// a final one, it must be called before the cache is closed.
+func startCacheSnapshots(cache services.CacheService, cfg *config.Config, l *logger.Logger) func() {
+	memory, ok := cache.(*services.MemoryCacheService)
+	if !ok || cfg.Cache.SnapshotPath == "" {
+		return func() {}
+	}
+
+	save := func() bool {
+		if err := memory.SaveSnapshot(cfg.Cache.SnapshotPath); err != nil {
+			l.Error(fmt.Errorf("app - cache snapshot: %w", err))
+			return false
+		}
+		return true
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if cfg.Cache.SnapshotInterval <= 0 {
+			<-done
+			return
+		}
+
+		ticker := time.NewTicker(cfg.Cache.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		if save() {
+			l.Info("Cache snapshot saved to %s", cfg.Cache.SnapshotPath)
+		}
+	}
+}
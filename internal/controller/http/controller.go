@@ -1,8 +1,12 @@
 package http
 
 import (
+	"context"
+	"time"
+
 	"DevelopsToday/config"
 	v1 "DevelopsToday/internal/controller/http/v1"
+	"DevelopsToday/internal/controller/http/v1/admin"
 	"DevelopsToday/internal/controller/http/v1/auth"
 	"DevelopsToday/internal/controller/http/v1/cat"
 	"DevelopsToday/internal/controller/http/v1/mission"
@@ -36,6 +40,13 @@ func NewV1Controller(
 	cfg *config.Config,
 	l logger.Interface,
 	jwtService *services.JWTService,
+	cache services.CacheService,
+	events *services.EventBus,
+	rbac *services.RBAC,
+	invitations *services.InvitationService,
+	notifier services.Notifier,
+	passwordPolicy *services.PasswordPolicy,
+	tasks *services.TaskQueue,
 ) {
 	// Middleware
 	engine.Use(middleware.LoggerMiddleware(l))
@@ -52,54 +63,115 @@ func NewV1Controller(
 		engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	// Repositories
-	catRepo := store.Cat()
-	missionRepo := store.Mission()
-	targetRepo := store.Target()
+	// Domain events
+	events.Subscribe(services.EventMissionCompleted, func(_ context.Context, e services.Event) {
+		if completed, ok := e.(services.MissionCompleted); ok {
+			l.Info("Mission %d completed automatically", completed.MissionID)
+		}
+	})
+	events.Subscribe(services.EventRefreshTokenReused, func(_ context.Context, e services.Event) {
+		if reused, ok := e.(services.RefreshTokenReused); ok {
+			l.Warn("security audit: refresh token reuse detected, session revoked: user_id=%d session_id=%s jti=%s ip=%s user_agent=%q",
+				reused.UserID, reused.SessionID, reused.TokenID, reused.IP, reused.UserAgent)
+		}
+	})
+
+	events.Subscribe(services.EventLoginLocked, func(_ context.Context, e services.Event) {
+		if locked, ok := e.(services.LoginLocked); ok {
+			l.Warn("security audit: login locked after %d failed attempts: %s=%s until=%s",
+				locked.Failures, locked.Scope, locked.Subject, locked.Until.Format(time.RFC3339))
+		}
+	})
+	events.Subscribe(services.EventLoginUnlocked, func(_ context.Context, e services.Event) {
+		if unlocked, ok := e.(services.LoginUnlocked); ok {
+			l.Info("security audit: login unlocked: %s=%s reason=%s", unlocked.Scope, unlocked.Subject, unlocked.Reason)
+		}
+	})
 
 	// Services
 	catHandlerService := cat.NewImplService(
 		services.NewBreed(),
-		services.NewCat(catRepo),
+		services.NewCachedCat(services.NewCat(store.Cat()), cache, cfg.Cache.CatTTL),
 	)
 
 	missionHandlerService := mission.NewImplService(
-		services.NewMission(missionRepo),
+		services.NewCachedMission(services.NewMission(store), cache, cfg.Cache.MissionTTL),
 	)
 
 	targetHandlerService := target.NewImplService(
-		services.NewTarget(targetRepo, missionRepo),
+		services.NewCachedTarget(services.NewTarget(store, events), cache),
 	)
 
-	// Auth handler
-	authHandler := auth.NewHandler(store.User(), jwtService, l)
+	// Auth handlers
+	loginGuard := services.NewLoginGuard(cfg.Login, cache, events)
+	passwords := services.NewPasswordService(store.User(), cache, jwtService, notifier, passwordPolicy, cfg.PasswordReset)
+	authHandler := auth.NewHandler(store.User(), jwtService, invitations, loginGuard, passwords, tasks, l)
+	adminHandler := admin.NewHandler(services.NewUserAdminService(store, rbac, jwtService, invitations, loginGuard), l)
+
+	// Public keys for verifying access tokens outside this service
+	engine.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Rate limits
+	var limiter *services.RateLimiter
+	if cfg.RateLimit.Enabled {
+		limiter = services.NewRateLimiter(cache)
+	}
+	window := cfg.RateLimit.Window
+	ipLimit := middleware.RateLimit(limiter, "ip",
+		services.RateLimit{Requests: cfg.RateLimit.IPRequests, Window: window}, middleware.ClientIPKey, l)
+	userLimit := middleware.RateLimit(limiter, "user",
+		services.RateLimit{Requests: cfg.RateLimit.UserRequests, Window: window}, middleware.UserKey, l)
+	authLimit := middleware.RateLimit(limiter, "auth",
+		services.RateLimit{Requests: cfg.RateLimit.AuthRequests, Window: cfg.RateLimit.AuthWindow}, middleware.RouteKey(middleware.ClientIPKey), l)
 
 	// API v1 group
 	v1Group := engine.Group("/v1")
+	v1Group.Use(ipLimit)
 	{
 		// Auth routes (no authentication required)
 		authGroup := v1Group.Group("/auth")
+		authGroup.Use(authLimit)
 		{
 			authGroup.POST("/register", authHandler.Register)
 			authGroup.POST("/login", authHandler.Login)
 			authGroup.POST("/refresh", authHandler.Refresh)
+			authGroup.POST("/password/forgot", authHandler.ForgotPassword)
+			authGroup.POST("/password/reset", authHandler.ResetPassword)
 		}
 
 		// Protected auth routes
 		protectedAuthGroup := v1Group.Group("/auth")
-		protectedAuthGroup.Use(middleware.AuthMiddleware(jwtService, l))
+		protectedAuthGroup.Use(middleware.AuthMiddleware(jwtService, l), userLimit)
 		{
 			protectedAuthGroup.POST("/logout", authHandler.Logout)
 			protectedAuthGroup.GET("/me", authHandler.Me)
+			protectedAuthGroup.POST("/password/change", authHandler.ChangePassword)
+			protectedAuthGroup.GET("/sessions", authHandler.ListSessions)
+			protectedAuthGroup.DELETE("/sessions", authHandler.RevokeAllSessions)
+			protectedAuthGroup.DELETE("/sessions/:sid", authHandler.RevokeSession)
+		}
+
+		// Admin routes
+		adminGroup := v1Group.Group("/admin")
+		adminGroup.Use(middleware.AuthMiddleware(jwtService, l), userLimit, middleware.RequirePermission(rbac, services.PermissionUsersManage))
+		{
+			adminGroup.GET("/users", adminHandler.ListUsers)
+			adminGroup.GET("/users/:id", adminHandler.GetUser)
+			adminGroup.PUT("/users/:id/role", adminHandler.ChangeRole)
+			adminGroup.DELETE("/users/:id", adminHandler.DeleteUser)
+			adminGroup.POST("/users/:id/revoke-tokens", adminHandler.RevokeUserTokens)
+			adminGroup.POST("/users/:id/unlock", adminHandler.UnlockLogin)
+			adminGroup.POST("/invitations", adminHandler.CreateInvitation)
+			adminGroup.GET("/audit-log", adminHandler.AuditLog)
 		}
 
 		// Protected API routes
 		protectedGroup := v1Group.Group("")
-		protectedGroup.Use(middleware.AuthMiddleware(jwtService, l))
+		protectedGroup.Use(middleware.AuthMiddleware(jwtService, l), userLimit)
 		{
-			v1.NewSpyCatsRoutes(protectedGroup, catHandlerService, l)
-			v1.NewMissionsRoutes(protectedGroup, missionHandlerService, l)
-			v1.NewTargetsRoutes(protectedGroup, targetHandlerService, l)
+			v1.NewSpyCatsRoutes(protectedGroup, catHandlerService, rbac, l)
+			v1.NewMissionsRoutes(protectedGroup, missionHandlerService, rbac, l)
+			v1.NewTargetsRoutes(protectedGroup, targetHandlerService, rbac, l)
 		}
 	}
 }
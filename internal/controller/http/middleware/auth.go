@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -32,13 +33,12 @@ func AuthMiddleware(jwtService *services.JWTService, logger logger.Interface) gi
 			return
 		}
 
-		if jwtService.IsTokenBlacklisted(token) {
+		claims, err := jwtService.AuthenticateToken(token)
+		if errors.Is(err, services.ErrTokenRevoked) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
 			c.Abort()
 			return
 		}
-
-		claims, err := jwtService.ValidateToken(token)
 		if err != nil {
 			logger.Error("Token validation failed: %v", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -49,6 +49,7 @@ func AuthMiddleware(jwtService *services.JWTService, logger logger.Interface) gi
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Set("token", token)
 
 		c.Next()
@@ -77,6 +78,27 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission lets the request through only when the role of the
+// authenticated user grants permission
+func RequirePermission(rbac *services.RBAC, permission services.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, exists := c.Get("role")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if !rbac.Can(userRole.(string), permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func OptionalAuth(jwtService *services.JWTService, logger logger.Interface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -96,12 +118,7 @@ func OptionalAuth(jwtService *services.JWTService, logger logger.Interface) gin.
 			return
 		}
 
-		if jwtService.IsTokenBlacklisted(token) {
-			c.Next()
-			return
-		}
-
-		claims, err := jwtService.ValidateToken(token)
+		claims, err := jwtService.AuthenticateToken(token)
 		if err != nil {
 			c.Next()
 			return
@@ -110,6 +127,7 @@ func OptionalAuth(jwtService *services.JWTService, logger logger.Interface) gin.
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Set("token", token)
 
 		c.Next()
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"DevelopsToday/internal/dto"
+	"DevelopsToday/internal/repo"
+	"DevelopsToday/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,7 +35,7 @@ func GlobalErrorHandler() gin.HandlerFunc {
 		c.Next()
 
 		if len(c.Errors) > 0 {
-			err := c.Errors.Last().Err
+			err := resolveError(c.Errors.Last().Err)
 
 			var appErr *AppError
 			var statusCode int
@@ -120,11 +123,18 @@ var (
 	ErrForbidden    = NewAuthError("FORBIDDEN", "Access denied", http.StatusForbidden)
 	ErrInvalidCreds = NewAuthError("INVALID_CREDENTIALS", "Invalid username or password", http.StatusUnauthorized)
 
+	ErrInvitationRequired = NewAuthError("INVITATION_REQUIRED", "Registration requires an invitation code", http.StatusForbidden)
+	ErrInvalidInvitation  = NewAuthError("INVALID_INVITATION", "Invalid or expired invitation code", http.StatusForbidden)
+	ErrWrongPassword      = NewAuthError("WRONG_PASSWORD", "Current password is incorrect", http.StatusForbidden)
+	ErrInvalidResetToken  = NewAuthError("INVALID_RESET_TOKEN", "Invalid or expired password reset token", http.StatusBadRequest)
+	ErrLoginLocked        = NewAuthError("LOGIN_LOCKED", "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+
 	ErrNotFound        = NewAppError("NOT_FOUND", "Resource not found", http.StatusNotFound)
 	ErrUserNotFound    = NewAppError("USER_NOT_FOUND", "User not found", http.StatusNotFound)
 	ErrCatNotFound     = NewAppError("CAT_NOT_FOUND", "Cat not found", http.StatusNotFound)
 	ErrMissionNotFound = NewAppError("MISSION_NOT_FOUND", "Mission not found", http.StatusNotFound)
 	ErrTargetNotFound  = NewAppError("TARGET_NOT_FOUND", "Target not found", http.StatusNotFound)
+	ErrSessionNotFound = NewAppError("SESSION_NOT_FOUND", "Session not found", http.StatusNotFound)
 
 	ErrConflict    = NewAppError("CONFLICT", "Resource already exists", http.StatusConflict)
 	ErrUserExists  = NewAppError("USER_EXISTS", "User already exists", http.StatusConflict)
@@ -134,8 +144,76 @@ var (
 	ErrInvalidInput = NewAppError("INVALID_INPUT", "Invalid input data", http.StatusBadRequest)
 	ErrMissingField = NewAppError("MISSING_FIELD", "Required field is missing", http.StatusBadRequest)
 
+	ErrRateLimited = NewAppError("RATE_LIMITED", "Too many requests, try again later", http.StatusTooManyRequests)
+	ErrBusy        = NewAppError("BUSY", "The server is busy, try again later", http.StatusServiceUnavailable)
+
 	ErrCatBusy         = NewBusinessError("CAT_BUSY", "Cat is already assigned to another mission", http.StatusConflict)
 	ErrMissionComplete = NewBusinessError("MISSION_COMPLETE", "Mission is already completed", http.StatusBadRequest)
 	ErrTargetComplete  = NewBusinessError("TARGET_COMPLETE", "Target is already completed", http.StatusBadRequest)
 	ErrInvalidBreed    = NewBusinessError("INVALID_BREED", "Invalid cat breed", http.StatusBadRequest)
+
+	ErrInvalidTargets    = NewBusinessError("INVALID_TARGETS", "Mission must have between 1 and 3 targets", http.StatusBadRequest)
+	ErrTargetsIncomplete = NewBusinessError("TARGETS_INCOMPLETE", "All targets must be completed first", http.StatusBadRequest)
+	ErrMissionAssigned   = NewBusinessError("MISSION_ASSIGNED", "Cannot delete mission with an assigned cat", http.StatusBadRequest)
+
+	ErrUnknownRole      = NewBusinessError("UNKNOWN_ROLE", "Role is not defined", http.StatusBadRequest)
+	ErrSelfModification = NewBusinessError("SELF_MODIFICATION", "Admins cannot change their own role or delete themselves", http.StatusConflict)
 )
+
+// domainErrors maps errors returned by services and repositories to the
+// catalog above. More specific errors must come before generic ones.
+var domainErrors = []struct {
+	domain error
+	api    error
+}{
+	{repo.ErrCatNotFound, ErrCatNotFound},
+	{repo.ErrMissionNotFound, ErrMissionNotFound},
+	{repo.ErrTargetNotFound, ErrTargetNotFound},
+	{repo.ErrUserNotFound, ErrUserNotFound},
+	{services.ErrSessionNotFound, ErrSessionNotFound},
+	{repo.ErrNotFound, ErrNotFound},
+	{repo.ErrCatBusy, ErrCatBusy},
+	{repo.ErrMissionComplete, ErrMissionComplete},
+	{services.ErrTargetComplete, ErrTargetComplete},
+	{services.ErrInvalidTargetCount, ErrInvalidTargets},
+	{services.ErrTargetsIncomplete, ErrTargetsIncomplete},
+	{services.ErrMissionAssigned, ErrMissionAssigned},
+	{services.ErrInvitationRequired, ErrInvitationRequired},
+	{services.ErrInvalidInvitation, ErrInvalidInvitation},
+	{services.ErrLoginLocked, ErrLoginLocked},
+	{services.ErrWrongPassword, ErrWrongPassword},
+	{services.ErrInvalidResetToken, ErrInvalidResetToken},
+	{services.ErrUnknownRole, ErrUnknownRole},
+	{services.ErrSelfModification, ErrSelfModification},
+	{services.ErrTaskQueueFull, ErrBusy},
+	{services.ErrTaskQueueClosed, ErrBusy},
+}
+
+// resolveError returns err itself when it is already one of the API error
+// types, its catalog counterpart when it is a known domain error, and err
+// unchanged otherwise
+func resolveError(err error) error {
+	var (
+		appErr        *AppError
+		validationErr *ValidationError
+		authErr       *AuthError
+		businessErr   *BusinessError
+	)
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.As(err, &validationErr):
+		return validationErr
+	case errors.As(err, &authErr):
+		return authErr
+	case errors.As(err, &businessErr):
+		return businessErr
+	}
+
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.domain) {
+			return mapping.api
+		}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"DevelopsToday/internal/services"
+	"DevelopsToday/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKey picks the subject a rate limit is counted for
+type RateLimitKey func(c *gin.Context) string
+
+// ClientIPKey limits each client IP
+func ClientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// UserKey limits each authenticated user, and clients that are not
+// authenticated by IP. It must run after AuthMiddleware.
+func UserKey(c *gin.Context) string {
+	if id := c.GetUint("user_id"); id != 0 {
+		return fmt.Sprintf("user:%d", id)
+	}
+	return ClientIPKey(c)
+}
+
+// RouteKey gives every route its own limit, e.g. RouteKey(ClientIPKey)
+// limits each client IP on each route separately
+func RouteKey(key RateLimitKey) RateLimitKey {
+	return func(c *gin.Context) string {
+		return c.Request.Method + " " + c.FullPath() + ":" + key(c)
+	}
+}
+
+// RateLimit rejects requests over limit with 429. Every response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers. A nil
+// limiter or a zero limit disables it.
+func RateLimit(limiter *services.RateLimiter, name string, limit services.RateLimit, key RateLimitKey, logger logger.Interface) gin.HandlerFunc {
+	if limiter == nil || limit.Requests <= 0 || limit.Window <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), name, key(c), limit)
+		if err != nil {
+			logger.Warn("Rate limit %s: %v", name, err)
+		}
+
+		reset := strconv.Itoa(seconds(result.Reset))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", reset)
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Window)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", reset)
+			_ = c.Error(ErrRateLimited)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,329 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+	"DevelopsToday/internal/services"
+	"DevelopsToday/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	users  *services.UserAdminService
+	logger logger.Interface
+}
+
+func NewHandler(users *services.UserAdminService, logger logger.Interface) *Handler {
+	return &Handler{
+		users:  users,
+		logger: logger,
+	}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Requires the users:manage permission
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} dto.PaginatedResponse{data=[]dto.UserResponse}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	var query dto.PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(middleware.ErrInvalidInput)
+		return
+	}
+
+	page := repo.Pagination{Page: query.Page, Limit: query.Limit}.Normalize()
+	users, total, err := h.users.List(context.Background(), page)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := make([]dto.UserResponse, 0, len(users))
+	for i := range users {
+		response = append(response, userResponse(&users[i]))
+	}
+
+	c.JSON(http.StatusOK, dto.PaginatedResponse{
+		Data: response,
+		Meta: dto.NewPaginationMeta(page.Page, page.Limit, total),
+	})
+}
+
+// GetUser godoc
+// @Summary Get user by ID
+// @Description Requires the users:manage permission
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/users/{id} [get]
+func (h *Handler) GetUser(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.Get(context.Background(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// ChangeRole godoc
+// @Summary Change the role of a user
+// @Description Requires the users:manage permission. The user is logged out everywhere and gets the new role on the next login.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.ChangeRoleRequest true "New role"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) ChangeRole(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
+		return
+	}
+
+	user, err := h.users.ChangeRole(context.Background(), actor(c), id, req.Role)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.logger.Info("security audit: role of user %d changed to %s by admin %d", id, req.Role, c.GetUint("user_id"))
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Requires the users:manage permission. Revokes every token of the user.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id} [delete]
+func (h *Handler) DeleteUser(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.users.Delete(context.Background(), actor(c), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.logger.Info("security audit: user %d deleted by admin %d", id, c.GetUint("user_id"))
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeUserTokens godoc
+// @Summary Revoke all tokens of a user
+// @Description Requires the users:manage permission. Log a user out of every device and invalidate their outstanding access tokens, e.g. when a device is compromised.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/revoke-tokens [post]
+func (h *Handler) RevokeUserTokens(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.users.RevokeTokens(context.Background(), actor(c), id); err != nil {
+		h.logger.Error("Failed to revoke tokens: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	h.logger.Warn("security audit: all tokens of user %d revoked by admin %d", id, c.GetUint("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "All tokens of the user revoked successfully"})
+}
+
+// UnlockLogin godoc
+// @Summary Unlock the login of a user
+// @Description Requires the users:manage permission. Lift the lockout and backoff after too many failed login attempts. Given an IP, the lockout of that client IP is lifted as well.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.UnlockLoginRequest false "Client IP to unlock as well"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/unlock [post]
+func (h *Handler) UnlockLogin(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req dto.UnlockLoginRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(middleware.NewValidationError("body", err.Error()))
+			return
+		}
+	}
+	// Stored the way the login guard sees client IPs
+	if req.IP != "" {
+		req.IP = net.ParseIP(req.IP).String()
+	}
+
+	if err := h.users.UnlockLogin(context.Background(), actor(c), id, req.IP); err != nil {
+		h.logger.Error("Failed to unlock login: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	h.logger.Info("security audit: login of user %d unlocked by admin %d", id, c.GetUint("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Login unlocked successfully"})
+}
+
+// CreateInvitation godoc
+// @Summary Create an invitation code
+// @Description Requires the users:manage permission. The single-use code lets one user register when registration is by invitation only.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} dto.InvitationResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/invitations [post]
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	invitation, err := h.users.Invite(context.Background(), actor(c))
+	if err != nil {
+		h.logger.Error("Failed to create invitation: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.InvitationResponse{
+		Code:      invitation.Code,
+		ExpiresAt: invitation.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// AuditLog godoc
+// @Summary List the audit trail
+// @Description Requires the users:manage permission. Administrative actions, newest first.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param user_id query int false "Only actions performed on this user"
+// @Success 200 {object} dto.PaginatedResponse{data=[]models.AuditLog}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/audit-log [get]
+func (h *Handler) AuditLog(c *gin.Context) {
+	var query dto.AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(middleware.ErrInvalidInput)
+		return
+	}
+
+	filter := repo.AuditLogFilter{
+		SubjectID:  query.UserID,
+		Pagination: repo.Pagination{Page: query.Page, Limit: query.Limit}.Normalize(),
+	}
+	entries, total, err := h.users.AuditLog(context.Background(), filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PaginatedResponse{
+		Data: entries,
+		Meta: dto.NewPaginationMeta(filter.Page, filter.Limit, total),
+	})
+}
+
+// userID parses the id path parameter
+func userID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		_ = c.Error(middleware.NewValidationError("id", "must be a positive number"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// actor describes the admin making the request for the audit trail
+func actor(c *gin.Context) services.Actor {
+	return services.Actor{
+		UserID: c.GetUint("user_id"),
+		IP:     c.ClientIP(),
+	}
+}
+
+func userResponse(user *models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+	}
+}
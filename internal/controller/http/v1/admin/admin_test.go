@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo/mocks"
+	"DevelopsToday/internal/services"
+	"DevelopsToday/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRouter(t *testing.T) (*gin.Engine, *services.JWTService, *models.User) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		JWT: config.JWT{
+			Secret:          "test-secret-key-for-jwt-testing",
+			AccessTokenTTL:  900,
+			RefreshTokenTTL: 604800,
+		},
+		App: config.App{Name: "spy-cats-api"},
+	}
+	cache := services.NewMemoryCacheService()
+	t.Cleanup(func() { cache.Close() })
+	jwtService, err := services.NewJWTService(cfg, cache, nil)
+	require.NoError(t, err)
+	rbac, err := services.NewRBAC(config.RBAC{})
+	require.NoError(t, err)
+	invitations, err := services.NewInvitationService(config.Registration{}, cache, rbac)
+	require.NoError(t, err)
+
+	store := mocks.NewRepository()
+	user := &models.User{Username: "whiskers", Email: "whiskers@spycats.com", Password: "secret", Role: "agent"}
+	require.NoError(t, store.User().Create(context.Background(), user))
+
+	users := services.NewUserAdminService(store, rbac, jwtService, invitations, services.NewLoginGuard(config.Login{}, cache, nil))
+	handler := NewHandler(users, logger.New("error"))
+
+	router := gin.New()
+	router.Use(middleware.GlobalErrorHandler())
+	// Stands in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(100)) })
+	router.POST("/v1/admin/users/:id/revoke-tokens", handler.RevokeUserTokens)
+	router.POST("/v1/admin/users/:id/unlock", handler.UnlockLogin)
+
+	return router, jwtService, user
+}
+
+func TestAdminController_RevokeUserTokens(t *testing.T) {
+	t.Run("should revoke every token of the user", func(t *testing.T) {
+		router, jwtService, user := setupTestRouter(t)
+
+		tokens, err := jwtService.GenerateTokenPair(user.ID, user.Username, user.Role, services.DeviceInfo{})
+		require.NoError(t, err)
+		other, err := jwtService.GenerateTokenPair(user.ID+1, "shadow", "agent", services.DeviceInfo{})
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("POST", "/v1/admin/users/1/revoke-tokens", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, services.ErrTokenRevoked)
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, services.DeviceInfo{})
+		assert.Error(t, err)
+
+		// Other users keep their tokens
+		_, err = jwtService.AuthenticateToken(other.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return 404 for an unknown user", func(t *testing.T) {
+		router, _, _ := setupTestRouter(t)
+
+		req, _ := http.NewRequest("POST", "/v1/admin/users/999/revoke-tokens", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should reject IDs that are not positive numbers", func(t *testing.T) {
+		router, _, _ := setupTestRouter(t)
+
+		for _, id := range []string{"abc", "0", "-1", "18446744073709551616"} {
+			req, _ := http.NewRequest("POST", "/v1/admin/users/"+id+"/revoke-tokens", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, id)
+		}
+	})
+}
+
+func TestAdminController_UnlockLogin(t *testing.T) {
+	t.Run("the IP should be optional", func(t *testing.T) {
+		router, _, _ := setupTestRouter(t)
+
+		for _, body := range []string{"", `{}`, `{"ip": "203.0.113.7"}`, `{"ip": "2001:DB8::1"}`} {
+			req, _ := http.NewRequest("POST", "/v1/admin/users/1/unlock", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, body)
+		}
+	})
+
+	t.Run("should reject an IP that is not one", func(t *testing.T) {
+		router, _, _ := setupTestRouter(t)
+
+		req, _ := http.NewRequest("POST", "/v1/admin/users/1/unlock", strings.NewReader(`{"ip": "office"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"DevelopsToday/internal/controller/http/middleware"
@@ -13,39 +15,55 @@ import (
 	"DevelopsToday/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type Handler struct {
-	userRepo   repo.UserRepository
-	jwtService *services.JWTService
-	logger     logger.Interface
+	userRepo    repo.UserRepository
+	jwtService  *services.JWTService
+	invitations *services.InvitationService
+	loginGuard  *services.LoginGuard
+	passwords   *services.PasswordService
+	tasks       *services.TaskQueue
+	logger      logger.Interface
 }
 
-func NewHandler(userRepo repo.UserRepository, jwtService *services.JWTService, logger logger.Interface) *Handler {
+func NewHandler(
+	userRepo repo.UserRepository,
+	jwtService *services.JWTService,
+	invitations *services.InvitationService,
+	loginGuard *services.LoginGuard,
+	passwords *services.PasswordService,
+	tasks *services.TaskQueue,
+	logger logger.Interface,
+) *Handler {
 	return &Handler{
-		userRepo:   userRepo,
-		jwtService: jwtService,
-		logger:     logger,
+		userRepo:    userRepo,
+		jwtService:  jwtService,
+		invitations: invitations,
+		loginGuard:  loginGuard,
+		passwords:   passwords,
+		tasks:       tasks,
+		logger:      logger,
 	}
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with username, email and password
+// @Description Register a new user with username, email and password. The username and email are stored in lower case and must be unique regardless of case. The password has to meet the password policy. New users get the least privileged role. When registration is by invitation only an invite_code is required.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body dto.RegisterRequest true "Registration data"
 // @Success 201 {object} dto.AuthResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth/register [post]
 func (h *Handler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		_ = c.Error(err)
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
 		return
 	}
 
@@ -55,34 +73,52 @@ func (h *Handler) Register(c *gin.Context) {
 	if _, err := h.userRepo.FindByUsername(ctx, req.Username); err == nil {
 		_ = c.Error(middleware.ErrUserExists)
 		return
+	} else if !errors.Is(err, repo.ErrUserNotFound) {
+		_ = c.Error(err)
+		return
 	}
 
 	if _, err := h.userRepo.FindByEmail(ctx, req.Email); err == nil {
 		_ = c.Error(middleware.ErrEmailExists)
 		return
+	} else if !errors.Is(err, repo.ErrUserNotFound) {
+		_ = c.Error(err)
+		return
 	}
 
-	// Set default role if not provided
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
-	// Create user
+	// The password is checked before an invitation is used up, the hash set
+	// here is kept by BeforeCreate
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: req.Password,
-		Role:     req.Role,
+	}
+	if err := h.passwords.Assign(user, req.Password); err != nil {
+		_ = c.Error(passwordError("password", err))
+		return
 	}
 
+	// The role is never taken from the request, admins change it later
+	admission, err := h.invitations.Admit(ctx, req.InviteCode)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Create user
+	user.Role = admission.Role
+
 	if err := h.userRepo.Create(ctx, user); err != nil {
 		h.logger.Error("Failed to create user: %v", err)
+		// The invitation was not used after all
+		if err := h.invitations.Release(ctx, admission); err != nil {
+			h.logger.Error("Failed to release invitation: %v", err)
+		}
 		_ = c.Error(err)
 		return
 	}
 
 	// Generate tokens
-	tokens, err := h.jwtService.GenerateTokenPair(user.ID, user.Username, user.Role)
+	tokens, err := h.jwtService.GenerateTokenPair(user.ID, user.Username, user.Role, deviceInfo(c, req.Device))
 	if err != nil {
 		h.logger.Error("Failed to generate tokens: %v", err)
 		_ = c.Error(err)
@@ -107,7 +143,7 @@ func (h *Handler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user with username and password
+// @Description Authenticate user with username or email and password, both ignore case. After repeated failures for a username or client IP further attempts are delayed, and eventually locked out, with a Retry-After header telling how long to wait.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -115,40 +151,63 @@ func (h *Handler) Register(c *gin.Context) {
 // @Success 200 {object} dto.AuthResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Header 429 {integer} Retry-After "Seconds to wait before the next attempt"
 // @Failure 500 {object} map[string]string
 // @Router /auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		_ = c.Error(err)
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
 		return
 	}
 
 	ctx := context.Background()
+	ip := c.ClientIP()
 
 	// Find user
-	user, err := h.userRepo.FindByUsername(ctx, req.Username)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			_ = c.Error(middleware.ErrInvalidCreds)
-			return
-		}
+	user, err := h.userRepo.FindByLogin(ctx, req.Username)
+	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
 		h.logger.Error("Failed to find user: %v", err)
 		_ = c.Error(err)
 		return
 	}
 
-	// Check password
-	if !user.CheckPassword(req.Password) {
-		_ = c.Error(middleware.ErrInvalidCreds)
+	// Failures are counted per account, whether it is logged into by
+	// username or by email. Unknown logins are counted by name.
+	subject := req.Username
+	if user != nil {
+		subject = user.Username
+	}
+
+	// Brute-force protection
+	if err := h.loginGuard.Check(ctx, subject, ip); err != nil {
+		setRetryAfter(c, err)
+		_ = c.Error(err)
+		return
+	}
+
+	// Check password, unknown logins take as long as wrong passwords
+	if !h.passwords.Verify(user, req.Password) {
+		h.loginFailed(ctx, c, subject, ip)
 		return
 	}
 
+	if err := h.loginGuard.Success(ctx, subject); err != nil {
+		h.logger.Error("Failed to reset failed logins: %v", err)
+	}
+
+	// Hashes made with an old bcrypt cost are upgraded while the password is
+	// at hand, a failure only postpones it to the next login
+	if err := h.passwords.Upgrade(ctx, user, req.Password); err != nil {
+		h.logger.Error("Failed to rehash password: %v", err)
+	}
+
 	// Generate tokens
-	tokens, err := h.jwtService.GenerateTokenPair(user.ID, user.Username, user.Role)
+	tokens, err := h.jwtService.GenerateTokenPair(user.ID, user.Username, user.Role, deviceInfo(c, req.Device))
 	if err != nil {
 		h.logger.Error("Failed to generate tokens: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -168,9 +227,27 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// loginFailed counts the failed attempt. Unknown usernames are counted too,
+// so the response does not tell whether an account exists.
+func (h *Handler) loginFailed(ctx context.Context, c *gin.Context, subject, ip string) {
+	if err := h.loginGuard.Failure(ctx, subject, ip); err != nil {
+		h.logger.Error("Failed to record failed login: %v", err)
+	}
+	_ = c.Error(middleware.ErrInvalidCreds)
+}
+
+// setRetryAfter tells a locked out client how many seconds to wait
+func setRetryAfter(c *gin.Context, err error) {
+	var locked *services.LoginLockedError
+	if errors.As(err, &locked) {
+		seconds := int((locked.RetryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+}
+
 // Refresh godoc
 // @Summary Refresh access token
-// @Description Get new access token using refresh token
+// @Description Get new access token using refresh token. The refresh token is rotated, reusing an old one revokes its session.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -183,13 +260,13 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) Refresh(c *gin.Context) {
 	var req dto.RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
 		return
 	}
 
-	tokens, err := h.jwtService.RefreshToken(req.RefreshToken)
+	tokens, err := h.jwtService.RefreshToken(req.RefreshToken, deviceInfo(c, ""))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		_ = c.Error(middleware.ErrInvalidToken)
 		return
 	}
 
@@ -198,7 +275,7 @@ func (h *Handler) Refresh(c *gin.Context) {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Revoke user's refresh token
+// @Description Revoke the current access token and its session, other devices stay logged in
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -210,19 +287,123 @@ func (h *Handler) Refresh(c *gin.Context) {
 func (h *Handler) Logout(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		_ = c.Error(middleware.ErrUnauthorized)
 		return
 	}
 
-	if err := h.jwtService.RevokeToken(userID.(uint)); err != nil {
-		h.logger.Error("Failed to revoke token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+	// Without this the access token keeps working until it expires
+	if err := h.jwtService.BlacklistToken(c.GetString("token")); err != nil {
+		h.logger.Error("Failed to blacklist token: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	// A session that is already gone needs no revoking
+	err := h.jwtService.RevokeSession(userID.(uint), c.GetString("session_id"))
+	if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+		h.logger.Error("Failed to revoke session: %v", err)
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// ListSessions godoc
+// @Summary List sessions
+// @Description List the devices the current user is logged in on
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.SessionResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		_ = c.Error(middleware.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := h.jwtService.ListSessions(userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list sessions: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	current := c.GetString("session_id")
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, dto.SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == current,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession godoc
+// @Summary Revoke session
+// @Description Log out one of the current user's devices
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param sid path string true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/sessions/{sid} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		_ = c.Error(middleware.ErrUnauthorized)
+		return
+	}
+
+	if err := h.jwtService.RevokeSession(userID.(uint), c.Param("sid")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions godoc
+// @Summary Revoke all sessions
+// @Description Log out every device of the current user, including this one
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/sessions [delete]
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		_ = c.Error(middleware.ErrUnauthorized)
+		return
+	}
+
+	if err := h.jwtService.RevokeAllSessions(userID.(uint)); err != nil {
+		h.logger.Error("Failed to revoke sessions: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked successfully"})
+}
+
 // Me godoc
 // @Summary Get current user
 // @Description Get current authenticated user information
@@ -236,7 +417,7 @@ func (h *Handler) Logout(c *gin.Context) {
 func (h *Handler) Me(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		_ = c.Error(middleware.ErrUnauthorized)
 		return
 	}
 
@@ -244,7 +425,7 @@ func (h *Handler) Me(c *gin.Context) {
 	user, err := h.userRepo.FindByID(ctx, userID.(uint))
 	if err != nil {
 		h.logger.Error("Failed to find user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -259,3 +440,133 @@ func (h *Handler) Me(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the password of the current user. The new password has to meet the password policy. Every session is revoked, including this one, so the user has to log in again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/change [post]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	if err := h.passwords.Change(context.Background(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if !errors.Is(err, services.ErrWrongPassword) && !errors.Is(err, services.ErrWeakPassword) {
+			h.logger.Error("Failed to change password: %v", err)
+		}
+		_ = c.Error(passwordError("new_password", err))
+		return
+	}
+
+	h.logger.Info("security audit: password of user %d changed, all sessions revoked", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully, please log in again"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset link
+// @Description Send a single-use, time-limited password reset link to the email of the account. The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Email of the account"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
+		return
+	}
+
+	// Looking the user up and sending the email only happens for registered
+	// addresses, answering first keeps the response time from telling them apart
+	email := req.Email
+	err := h.tasks.Submit(func(ctx context.Context) {
+		if err := h.passwords.RequestReset(ctx, email); err != nil {
+			h.logger.Error("Failed to request password reset: %v", err)
+		}
+	})
+	if err != nil {
+		h.logger.Error("Failed to queue password reset: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from a password reset link. The new password has to meet the password policy, otherwise the token stays usable. Every session of the user is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(middleware.NewValidationError("body", err.Error()))
+		return
+	}
+
+	if err := h.passwords.Reset(context.Background(), req.Token, req.NewPassword); err != nil {
+		if !errors.Is(err, services.ErrInvalidResetToken) && !errors.Is(err, services.ErrWeakPassword) {
+			h.logger.Error("Failed to reset password: %v", err)
+		}
+		_ = c.Error(passwordError("new_password", err))
+		return
+	}
+
+	h.logger.Info("security audit: password reset with a reset token")
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully, please log in"})
+}
+
+// passwordError reports a password rejected by the policy as a validation
+// error of field, other errors are returned unchanged
+func passwordError(field string, err error) error {
+	var policyErr *services.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return middleware.NewValidationError(field, policyErr.Error())
+	}
+	return err
+}
+
+// deviceInfo describes the client of the request for its new session
+func deviceInfo(c *gin.Context, device string) services.DeviceInfo {
+	return services.DeviceInfo{
+		Device:    device,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys access tokens are signed with, for services verifying them on their own. Empty when tokens are signed with HMAC.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+	"DevelopsToday/internal/repo/mocks"
+	"DevelopsToday/internal/services"
+	"DevelopsToday/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type testEnv struct {
+	router      *gin.Engine
+	jwt         *services.JWTService
+	cache       services.CacheService
+	store       *mocks.Mocks
+	guard       *services.LoginGuard
+	invitations *services.InvitationService
+	passwords   *services.PasswordService
+	tasks       *services.TaskQueue
+	notifier    *recordingNotifier
+}
+
+type recordingNotifier struct {
+	notifications []services.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification services.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func setupTestRouter(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		JWT: config.JWT{
+			Secret:          "test-secret-key-for-jwt-testing",
+			AccessTokenTTL:  900,
+			RefreshTokenTTL: 604800,
+		},
+		App: config.App{Name: "spy-cats-api"},
+	}
+	cache := services.NewMemoryCacheService()
+	t.Cleanup(func() { cache.Close() })
+	jwtService, err := services.NewJWTService(cfg, cache, nil)
+	require.NoError(t, err)
+	policy, err := services.NewPasswordPolicy(config.Password{BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	rbac, err := services.NewRBAC(config.RBAC{})
+	require.NoError(t, err)
+	invitations, err := services.NewInvitationService(config.Registration{Mode: services.RegistrationInvite}, cache, rbac)
+	require.NoError(t, err)
+
+	store := mocks.NewRepository()
+	guard := services.NewLoginGuard(config.Login{
+		FreeAttempts:        100,
+		UserLockoutAttempts: 3,
+		LockoutDuration:     time.Minute,
+	}, cache, nil)
+	notifier := &recordingNotifier{}
+	passwords := services.NewPasswordService(store.User(), cache, jwtService, notifier, policy, config.PasswordReset{})
+	tasks := services.NewTaskQueue(10, 1)
+	t.Cleanup(func() { tasks.Close(context.Background()) })
+
+	log := logger.New("error")
+	handler := NewHandler(store.User(), jwtService, invitations, guard, passwords, tasks, log)
+
+	router := gin.New()
+	router.Use(middleware.GlobalErrorHandler())
+	router.POST("/v1/auth/register", handler.Register)
+	router.POST("/v1/auth/login", handler.Login)
+	router.POST("/v1/auth/password/forgot", handler.ForgotPassword)
+	protected := router.Group("/v1", middleware.AuthMiddleware(jwtService, log))
+	{
+		protected.POST("/auth/logout", handler.Logout)
+		protected.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	}
+
+	return &testEnv{
+		router:      router,
+		jwt:         jwtService,
+		cache:       cache,
+		store:       store,
+		guard:       guard,
+		invitations: invitations,
+		passwords:   passwords,
+		tasks:       tasks,
+		notifier:    notifier,
+	}
+}
+
+func authorized(method, path, token string) *http.Request {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAuthController_Logout(t *testing.T) {
+	t.Run("should blacklist the access token and keep other devices logged in", func(t *testing.T) {
+		env := setupTestRouter(t)
+		router, jwtService := env.router, env.jwt
+
+		laptop, err := jwtService.GenerateTokenPair(1, "whiskers", "agent", services.DeviceInfo{Device: "laptop"})
+		require.NoError(t, err)
+		phone, err := jwtService.GenerateTokenPair(1, "whiskers", "agent", services.DeviceInfo{Device: "phone"})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, authorized("POST", "/v1/auth/logout", laptop.AccessToken))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		claims, err := jwtService.ValidateToken(laptop.AccessToken, services.TokenTypeAccess)
+		require.NoError(t, err)
+		blacklisted, err := env.cache.Exists(context.Background(), "blacklist:"+claims.ID)
+		require.NoError(t, err)
+		assert.True(t, blacklisted)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, authorized("GET", "/v1/ping", laptop.AccessToken))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, authorized("GET", "/v1/ping", phone.AccessToken))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should reject a token that is already logged out", func(t *testing.T) {
+		env := setupTestRouter(t)
+		router, jwtService := env.router, env.jwt
+
+		tokens, err := jwtService.GenerateTokenPair(1, "whiskers", "agent", services.DeviceInfo{})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, authorized("POST", "/v1/auth/logout", tokens.AccessToken))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, authorized("POST", "/v1/auth/logout", tokens.AccessToken))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func login(router *gin.Engine, username, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.LoginRequest{Username: username, Password: password})
+	req, _ := http.NewRequest("POST", "/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthController_Login(t *testing.T) {
+	t.Run("failures by username and by email should lock the same account", func(t *testing.T) {
+		env := setupTestRouter(t)
+		user := &models.User{Username: "whiskers", Email: "whiskers@spycats.com", Password: "correct-horse-battery"}
+		require.NoError(t, env.store.User().Create(context.Background(), user))
+
+		for _, name := range []string{"WHISKERS@spycats.com", "whiskers", "Whiskers@SpyCats.com"} {
+			w := login(env.router, name, "wrong-password")
+			require.Equal(t, http.StatusUnauthorized, w.Code, name)
+		}
+
+		w := login(env.router, "Whiskers", "correct-horse-battery")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// What the admin unlock does
+		require.NoError(t, env.guard.Unlock(context.Background(), user.Username, ""))
+		w = login(env.router, "whiskers@spycats.com", "correct-horse-battery")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown logins should be counted by name", func(t *testing.T) {
+		env := setupTestRouter(t)
+
+		for i := 0; i < 3; i++ {
+			w := login(env.router, "Nobody", "wrong-password")
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+
+		w := login(env.router, "nobody", "wrong-password")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		w = login(env.router, "somebody", "wrong-password")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+// failingCreate stands in for a repository losing a unique index race
+type failingCreate struct {
+	repo.UserRepository
+}
+
+func (failingCreate) Create(context.Context, *models.User) error {
+	return errors.New("duplicate key value violates unique constraint")
+}
+
+func register(router *gin.Engine, username, code string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.RegisterRequest{
+		Username:   username,
+		Email:      username + "@spycats.com",
+		Password:   "correct-horse-battery",
+		InviteCode: code,
+	})
+	req, _ := http.NewRequest("POST", "/v1/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthController_Register(t *testing.T) {
+	t.Run("an invitation should be used up by one registration", func(t *testing.T) {
+		env := setupTestRouter(t)
+		invitation, err := env.invitations.Create(context.Background(), 1)
+		require.NoError(t, err)
+
+		w := register(env.router, "whiskers", invitation.Code)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = register(env.router, "mittens", invitation.Code)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("a failed registration should not use up the invitation", func(t *testing.T) {
+		env := setupTestRouter(t)
+		invitation, err := env.invitations.Create(context.Background(), 1)
+		require.NoError(t, err)
+
+		failing := NewHandler(failingCreate{env.store.User()}, env.jwt, env.invitations, env.guard, env.passwords, env.tasks, logger.New("error"))
+		router := gin.New()
+		router.Use(middleware.GlobalErrorHandler())
+		router.POST("/v1/auth/register", failing.Register)
+
+		w := register(router, "whiskers", invitation.Code)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+
+		w = register(env.router, "whiskers", invitation.Code)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func forgotPassword(router *gin.Engine, email string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.ForgotPasswordRequest{Email: email})
+	req, _ := http.NewRequest("POST", "/v1/auth/password/forgot", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthController_ForgotPassword(t *testing.T) {
+	t.Run("should answer alike for any email and send the link in the background", func(t *testing.T) {
+		env := setupTestRouter(t)
+		user := &models.User{Username: "whiskers", Email: "whiskers@spycats.com", Password: "correct-horse-battery"}
+		require.NoError(t, env.store.User().Create(context.Background(), user))
+
+		assert.Equal(t, http.StatusAccepted, forgotPassword(env.router, "whiskers@spycats.com").Code)
+		assert.Equal(t, http.StatusAccepted, forgotPassword(env.router, "nobody@spycats.com").Code)
+
+		// Closing waits for the queued requests, as on shutdown
+		require.NoError(t, env.tasks.Close(context.Background()))
+		require.Len(t, env.notifier.notifications, 1)
+		assert.Equal(t, "whiskers@spycats.com", env.notifier.notifications[0].To)
+	})
+
+	t.Run("should answer 503 when the request cannot be queued", func(t *testing.T) {
+		env := setupTestRouter(t)
+		require.NoError(t, env.tasks.Close(context.Background()))
+
+		w := forgotPassword(env.router, "whiskers@spycats.com")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
 	"DevelopsToday/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -38,21 +41,22 @@ type Handler struct {
 //	@Success		201	{object}	models.Cat
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
 //	@Router			/cats [post]
 func (h *Handler) Create(ctx *gin.Context) {
 	var newCat models.Cat
 	if err := ctx.ShouldBindJSON(&newCat); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 
 	if !h.Service._validator.IsValid(newCat.Breed) {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid breed"})
+		_ = ctx.Error(middleware.ErrInvalidBreed)
 		return
 	}
 
 	if err := h.Service._catContext.Create(ctx, &newCat); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cat"})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -61,21 +65,58 @@ func (h *Handler) Create(ctx *gin.Context) {
 
 // List godoc
 //
-//	@Summary		List all cats
-//	@Description	Get list of all cats
+//	@Summary		List cats
+//	@Description	Get a paginated list of cats with optional filters and sorting
 //	@Tags			cats
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Success		200	{array}	models.Cat
-//	@Failure		401	{object}	map[string]interface{}
+//	@Param			page			query		int		false	"Page number"		default(1)
+//	@Param			limit			query		int		false	"Items per page"	default(10)
+//	@Param			sort			query		string	false	"Sort fields: id, name, breed, experience, salary (prefix with - for desc)"
+//	@Param			breed			query		string	false	"Breed"
+//	@Param			name			query		string	false	"Name prefix"
+//	@Param			min_experience	query		int		false	"Minimum experience"
+//	@Param			max_experience	query		int		false	"Maximum experience"
+//	@Param			min_salary		query		number	false	"Minimum salary"
+//	@Param			max_salary		query		number	false	"Maximum salary"
+//	@Success		200				{object}	dto.PaginatedResponse{data=[]models.Cat}
+//	@Failure		400				{object}	map[string]interface{}
+//	@Failure		401				{object}	map[string]interface{}
 //	@Router			/cats [get]
 func (h *Handler) List(ctx *gin.Context) {
-	cats, err := h.Service._catContext.GetAll(ctx)
+	var query dto.CatListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		_ = ctx.Error(middleware.ErrInvalidInput)
+		return
+	}
+
+	sort, err := repo.ParseSort(query.Sort, repo.CatSortFields)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cats"})
+		_ = ctx.Error(middleware.NewValidationError("sort", err.Error()))
 		return
 	}
-	ctx.JSON(http.StatusOK, cats)
+
+	filter := repo.CatFilter{
+		Breed:         query.Breed,
+		NamePrefix:    query.Name,
+		MinExperience: query.MinExperience,
+		MaxExperience: query.MaxExperience,
+		MinSalary:     query.MinSalary,
+		MaxSalary:     query.MaxSalary,
+		Sort:          sort,
+		Pagination:    repo.Pagination{Page: query.Page, Limit: query.Limit}.Normalize(),
+	}
+
+	cats, total, err := h.Service._catContext.List(ctx, filter)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.PaginatedResponse{
+		Data: cats,
+		Meta: dto.NewPaginationMeta(filter.Page, filter.Limit, total),
+	})
 }
 
 //	@Des
@@ -95,13 +136,13 @@ func (h *Handler) List(ctx *gin.Context) {
 func (h *Handler) GetByID(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	cat, err := h.Service._catContext.GetByID(ctx, uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Cat not found"})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -121,12 +162,13 @@ func (h *Handler) GetByID(ctx *gin.Context) {
 //	@Success		200		{object}	models.Cat
 //	@Failure		400		{object}	map[string]interface{}
 //	@Failure		401		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
 //	@Failure		404		{object}	map[string]interface{}
 //	@Router			/cats/{id}/salary [patch]
 func (h *Handler) UpdateSalary(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
@@ -134,18 +176,18 @@ func (h *Handler) UpdateSalary(ctx *gin.Context) {
 		Salary float64 `json:"salary"`
 	}
 	if bindErr := ctx.ShouldBindJSON(&body); bindErr != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 
 	if updateErr := h.Service._catContext.UpdateSalary(ctx, uint(id), body.Salary); updateErr != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Cat not found"})
+		_ = ctx.Error(updateErr)
 		return
 	}
 
 	cat, err := h.Service._catContext.GetByID(ctx, uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Salary updated, but cat fetch failed"})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -162,18 +204,19 @@ func (h *Handler) UpdateSalary(ctx *gin.Context) {
 //	@Param			id	path	int	true	"Cat ID"
 //	@Success		204	"No Content"
 //	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/cats/{id} [delete]
 func (h *Handler) Delete(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	err = h.Service._catContext.DeleteByID(ctx, uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Cat not found"})
+		_ = ctx.Error(err)
 		return
 	}
 
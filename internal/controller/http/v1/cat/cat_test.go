@@ -9,6 +9,8 @@ import (
 	"strconv"
 	"testing"
 
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/repo/mocks"
 	"DevelopsToday/internal/services"
@@ -49,6 +51,7 @@ func setupTestRouter() (*gin.Engine, *Service) {
 	handler := &Handler{Service: service}
 
 	router := gin.New()
+	router.Use(middleware.GlobalErrorHandler())
 	v1 := router.Group("/v1")
 	cats := v1.Group("/cats")
 	{
@@ -129,10 +132,85 @@ func TestCatController_List(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response []models.Cat
+		var response struct {
+			Data []models.Cat       `json:"data"`
+			Meta dto.PaginationMeta `json:"meta"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, len(response.Data), 5) // Should have at least 5 initial cats
+		assert.Equal(t, 1, response.Meta.Page)
+		assert.Equal(t, 10, response.Meta.Limit)
+		assert.GreaterOrEqual(t, response.Meta.Total, int64(5))
+	})
+
+	t.Run("should paginate and sort cats", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/cats?page=2&limit=2&sort=-salary", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []models.Cat       `json:"data"`
+			Meta dto.PaginationMeta `json:"meta"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 2)
+		assert.Equal(t, "Whiskers", response.Data[0].Name) // Mittens, Luna | Whiskers, Felix | Shadow
+		assert.Equal(t, "Felix", response.Data[1].Name)
+		assert.Equal(t, 2, response.Meta.Page)
+		assert.Equal(t, 3, response.Meta.TotalPages)
+	})
+
+	t.Run("should filter cats by breed and salary range", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/cats?breed=persian&min_salary=1000&max_experience=10", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []models.Cat       `json:"data"`
+			Meta dto.PaginationMeta `json:"meta"`
+		}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.GreaterOrEqual(t, len(response), 5) // Should have at least 5 initial cats
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, "Mittens", response.Data[0].Name)
+		assert.Equal(t, int64(1), response.Meta.Total)
+	})
+
+	t.Run("should filter cats by name prefix", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/cats?name=lu", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Luna")
+		assert.NotContains(t, w.Body.String(), "Whiskers")
+	})
+
+	t.Run("should fail with unsupported sort field", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/cats?sort=password", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should fail with invalid limit", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/cats?limit=1000", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
 	"DevelopsToday/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +30,8 @@ type Handler struct {
 // CreateRequest represents the request body for creating a mission
 type CreateRequest struct {
 	Targets []models.Target `json:"targets"`
+	// AutoComplete completes the mission once its last target is completed
+	AutoComplete bool `json:"auto_complete" example:"true"`
 }
 
 // AssignCatRequest represents the request body for assigning a cat
@@ -46,20 +51,22 @@ type AssignCatRequest struct {
 //	@Success		201		{object}	models.Mission
 //	@Failure		400		{object}	map[string]interface{}
 //	@Failure		401		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
 //	@Router			/missions [post]
 func (h *Handler) Create(ctx *gin.Context) {
 	var input CreateRequest
-	if err := ctx.ShouldBindJSON(&input); err != nil || len(input.Targets) < 1 || len(input.Targets) > 3 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid targets"})
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 
 	mission := &models.Mission{
-		Targets: input.Targets,
+		Targets:      input.Targets,
+		AutoComplete: input.AutoComplete,
 	}
 
 	if err := h.Service._missionContext.Create(ctx, mission); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create mission"})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -68,21 +75,61 @@ func (h *Handler) Create(ctx *gin.Context) {
 
 // List godoc
 //
-//	@Summary		List all missions
-//	@Description	Get all missions
+//	@Summary		List missions
+//	@Description	Get a paginated list of missions with optional filters
 //	@Tags			missions
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Success		200	{array}	models.Mission
-//	@Failure		401	{object}	map[string]interface{}
+//	@Param			page			query		int		false	"Page number"		default(1)
+//	@Param			limit			query		int		false	"Items per page"	default(10)
+//	@Param			sort			query		string	false	"Sort fields: id, cat_id, complete (prefix with - for desc)"
+//	@Param			complete		query		bool	false	"Completion status"
+//	@Param			cat_id			query		int		false	"Assigned cat ID"
+//	@Param			unassigned		query		bool	false	"Only missions without a cat"
+//	@Param			target_country	query		string	false	"Country of at least one target"
+//	@Param			include_targets	query		bool	false	"Include targets"	default(true)
+//	@Success		200				{object}	dto.PaginatedResponse{data=[]models.Mission}
+//	@Failure		400				{object}	map[string]interface{}
+//	@Failure		401				{object}	map[string]interface{}
 //	@Router			/missions [get]
 func (h *Handler) List(ctx *gin.Context) {
-	missions, err := h.Service._missionContext.GetAll(ctx)
+	var query dto.MissionListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		_ = ctx.Error(middleware.ErrInvalidInput)
+		return
+	}
+
+	if query.Unassigned && query.CatID != nil {
+		_ = ctx.Error(middleware.NewValidationError("cat_id", "cannot be combined with unassigned"))
+		return
+	}
+
+	sort, err := repo.ParseSort(query.Sort, repo.MissionSortFields)
+	if err != nil {
+		_ = ctx.Error(middleware.NewValidationError("sort", err.Error()))
+		return
+	}
+
+	filter := repo.MissionFilter{
+		Complete:      query.Complete,
+		CatID:         query.CatID,
+		Unassigned:    query.Unassigned,
+		TargetCountry: query.TargetCountry,
+		SkipTargets:   query.IncludeTargets != nil && !*query.IncludeTargets,
+		Sort:          sort,
+		Pagination:    repo.Pagination{Page: query.Page, Limit: query.Limit}.Normalize(),
+	}
+
+	missions, total, err := h.Service._missionContext.List(ctx, filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list missions"})
+		_ = ctx.Error(err)
 		return
 	}
-	ctx.JSON(http.StatusOK, missions)
+
+	ctx.JSON(http.StatusOK, dto.PaginatedResponse{
+		Data: missions,
+		Meta: dto.NewPaginationMeta(filter.Page, filter.Limit, total),
+	})
 }
 
 // GetByID godoc
@@ -98,10 +145,14 @@ func (h *Handler) List(ctx *gin.Context) {
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/missions/{id} [get]
 func (h *Handler) GetByID(ctx *gin.Context) {
-	id, _ := strconv.Atoi(ctx.Param("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
+		return
+	}
 	mission, err := h.Service._missionContext.GetByID(ctx, uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Mission not found"})
+		_ = ctx.Error(err)
 		return
 	}
 	ctx.JSON(http.StatusOK, mission)
@@ -120,17 +171,23 @@ func (h *Handler) GetByID(ctx *gin.Context) {
 //	@Success		200		{object}	models.Mission
 //	@Failure		400		{object}	map[string]interface{}
 //	@Failure		401		{object}	map[string]interface{}
-//	@Failure		404		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Failure		404		{object}	dto.ErrorResponse
+//	@Failure		409		{object}	dto.ErrorResponse
 //	@Router			/missions/{id}/assign [post]
 func (h *Handler) AssignCat(ctx *gin.Context) {
-	id, _ := strconv.Atoi(ctx.Param("id"))
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
+		return
+	}
 	var body AssignCatRequest
 	if err := ctx.ShouldBindJSON(&body); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 	if err := h.Service._missionContext.AssignCat(ctx, uint(id), body.CatID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign cat"})
+		_ = ctx.Error(err)
 		return
 	}
 	ctx.Status(http.StatusOK)
@@ -147,19 +204,20 @@ func (h *Handler) AssignCat(ctx *gin.Context) {
 //	@Success		200	{object}	models.Mission
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/missions/{id}/complete [post]
 func (h *Handler) MarkComplete(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	// Викликаємо сервісний метод
 	err = h.Service._missionContext.MarkComplete(ctx, uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -177,22 +235,19 @@ func (h *Handler) MarkComplete(ctx *gin.Context) {
 //	@Success		204	"No Content"
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/missions/{id} [delete]
 func (h *Handler) Delete(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	err = h.Service._missionContext.DeleteByID(ctx, uint(id))
 	if err != nil {
-		if err.Error() == "cannot delete assigned mission" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Mission not found"})
-		}
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -9,6 +9,8 @@ import (
 	"strconv"
 	"testing"
 
+	"DevelopsToday/internal/controller/http/middleware"
+	"DevelopsToday/internal/dto"
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/repo/mocks"
 	"DevelopsToday/internal/services"
@@ -17,16 +19,22 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+type listResponse struct {
+	Data []models.Mission   `json:"data"`
+	Meta dto.PaginationMeta `json:"meta"`
+}
+
 func setupTestRouter() (*gin.Engine, *Service) {
 	gin.SetMode(gin.TestMode)
 
 	store := mocks.NewRepository()
-	missionService := services.NewMission(store.Mission())
+	missionService := services.NewMission(store)
 
 	service := NewImplService(missionService)
 	handler := &Handler{Service: service}
 
 	router := gin.New()
+	router.Use(middleware.GlobalErrorHandler())
 	v1 := router.Group("/v1")
 	missions := v1.Group("/missions")
 	{
@@ -125,10 +133,91 @@ func TestMissionController_List(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response []models.Mission
+		var response listResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, len(response.Data), 5) // Should have at least 5 initial missions
+		assert.GreaterOrEqual(t, response.Meta.Total, int64(5))
+	})
+
+	t.Run("should filter open missions by target country", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?complete=false&target_country=japan", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response listResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.GreaterOrEqual(t, len(response), 5) // Should have at least 5 initial missions
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, uint(3), response.Data[0].ID)
+	})
+
+	t.Run("should filter missions by cat", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?cat_id=2", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response listResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, uint(2), response.Data[0].ID)
+	})
+
+	t.Run("should return unassigned missions without targets", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?unassigned=true&include_targets=false", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response listResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 1)
+		assert.Nil(t, response.Data[0].CatID)
+		assert.Empty(t, response.Data[0].Targets)
+	})
+
+	t.Run("should paginate missions", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?limit=2&page=3", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response listResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, uint(5), response.Data[0].ID)
+		assert.Equal(t, 3, response.Meta.TotalPages)
+	})
+
+	t.Run("should fail when cat_id is combined with unassigned", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?cat_id=1&unassigned=true", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should fail with invalid complete value", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/missions?complete=maybe", http.NoBody)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
@@ -196,7 +285,50 @@ func TestMissionController_AssignCat(t *testing.T) {
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "CAT_NOT_FOUND")
+	})
+
+	t.Run("should fail for cat busy with another mission", func(t *testing.T) {
+		assignReq := AssignCatRequest{CatID: 1} // Whiskers is on mission 1
+		jsonData, _ := json.Marshal(assignReq)
+
+		req, _ := http.NewRequest("POST", "/v1/missions/4/assign", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "CAT_BUSY")
+	})
+
+	t.Run("should fail for completed mission", func(t *testing.T) {
+		assignReq := AssignCatRequest{CatID: 2}
+		jsonData, _ := json.Marshal(assignReq)
+
+		req, _ := http.NewRequest("POST", "/v1/missions/2/assign", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "MISSION_COMPLETE")
+	})
+
+	t.Run("should fail for non-existing mission", func(t *testing.T) {
+		assignReq := AssignCatRequest{CatID: 2}
+		jsonData, _ := json.Marshal(assignReq)
+
+		req, _ := http.NewRequest("POST", "/v1/missions/999/assign", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "MISSION_NOT_FOUND")
 	})
 }
 
@@ -227,7 +359,8 @@ func TestMissionController_MarkComplete(t *testing.T) {
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "MISSION_NOT_FOUND")
 	})
 
 	t.Run("should fail with invalid ID", func(t *testing.T) {
@@ -250,6 +383,7 @@ func TestMissionController_Delete(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "MISSION_ASSIGNED")
 	})
 
 	t.Run("should succeed for unassigned mission", func(t *testing.T) {
@@ -1,45 +1,47 @@
 package v1
 
 import (
+	"DevelopsToday/internal/controller/http/middleware"
 	"DevelopsToday/internal/controller/http/v1/cat"
 	"DevelopsToday/internal/controller/http/v1/mission"
 	"DevelopsToday/internal/controller/http/v1/target"
+	"DevelopsToday/internal/services"
 	"DevelopsToday/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewSpyCatsRoutes(apiV1Group *gin.RouterGroup, service *cat.Service, l logger.Interface) {
+func NewSpyCatsRoutes(apiV1Group *gin.RouterGroup, service *cat.Service, rbac *services.RBAC, l logger.Interface) {
 	handler := &V1{cat: &cat.Handler{
 		Service: service,
 	}}
 
 	cats := apiV1Group.Group("/cats")
-	cats.POST("", handler.cat.Create)
+	cats.POST("", middleware.RequirePermission(rbac, services.PermissionCatsWrite), handler.cat.Create)
 	cats.GET("", handler.cat.List)
 	cats.GET("/:id", handler.cat.GetByID)
-	cats.PUT("/:id/salary", handler.cat.UpdateSalary)
-	cats.DELETE("/:id", handler.cat.Delete)
+	cats.PUT("/:id/salary", middleware.RequirePermission(rbac, services.PermissionSalaryUpdate), handler.cat.UpdateSalary)
+	cats.DELETE("/:id", middleware.RequirePermission(rbac, services.PermissionCatsWrite), handler.cat.Delete)
 }
-func NewMissionsRoutes(apiV1Group *gin.RouterGroup, service *mission.Service, l logger.Interface) {
+func NewMissionsRoutes(apiV1Group *gin.RouterGroup, service *mission.Service, rbac *services.RBAC, l logger.Interface) {
 	handler := &V1{mission: &mission.Handler{
 		Service: service,
 	}}
 	missions := apiV1Group.Group("/missions")
-	missions.POST("", handler.mission.Create)
+	missions.POST("", middleware.RequirePermission(rbac, services.PermissionMissionsWrite), handler.mission.Create)
 	missions.GET("", handler.mission.List)
 	missions.GET("/:id", handler.mission.GetByID)
-	missions.PUT("/:id/complete", handler.mission.MarkComplete)
-	missions.PUT("/:id/assign", handler.mission.AssignCat)
-	missions.DELETE("/:id", handler.mission.Delete)
+	missions.PUT("/:id/complete", middleware.RequirePermission(rbac, services.PermissionMissionsWrite), handler.mission.MarkComplete)
+	missions.PUT("/:id/assign", middleware.RequirePermission(rbac, services.PermissionMissionsAssign), handler.mission.AssignCat)
+	missions.DELETE("/:id", middleware.RequirePermission(rbac, services.PermissionMissionsWrite), handler.mission.Delete)
 }
-func NewTargetsRoutes(apiV1Group *gin.RouterGroup, service *target.Service, l logger.Interface) {
+func NewTargetsRoutes(apiV1Group *gin.RouterGroup, service *target.Service, rbac *services.RBAC, l logger.Interface) {
 	handler := &V1{target: &target.Handler{
 		Service: service,
 	}}
 	targets := apiV1Group.Group("/missions/:id/targets")
-	targets.POST("", handler.target.Add)
-	targets.DELETE("/:tid", handler.target.Delete)
-	targets.PUT("/:tid/notes", handler.target.UpdateNotes)
-	targets.PUT("/:tid/complete", handler.target.MarkComplete)
+	targets.POST("", middleware.RequirePermission(rbac, services.PermissionTargetsWrite), handler.target.Add)
+	targets.DELETE("/:tid", middleware.RequirePermission(rbac, services.PermissionTargetsWrite), handler.target.Delete)
+	targets.PUT("/:tid/notes", middleware.RequirePermission(rbac, services.PermissionTargetsNotes), handler.target.UpdateNotes)
+	targets.PUT("/:tid/complete", middleware.RequirePermission(rbac, services.PermissionTargetsComplete), handler.target.MarkComplete)
 }
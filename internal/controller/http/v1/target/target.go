@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"DevelopsToday/internal/controller/http/middleware"
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/services"
 
@@ -42,27 +43,24 @@ type UpdateNotesRequest struct {
 //	@Success		201		{object}	models.Target
 //	@Failure		400		{object}	map[string]interface{}
 //	@Failure		401		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
 //	@Failure		404		{object}	map[string]interface{}
 //	@Router			/missions/{id}/targets [post]
 func (h *Handler) Add(ctx *gin.Context) {
 	mid, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mission ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	var input models.Target
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target"})
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 
 	if err := h.Service._targetContext.Add(ctx, uint(mid), &input); err != nil {
-		if err.Error() == "cannot add target to completed mission" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Mission not found"})
-		}
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -89,28 +87,24 @@ func (h *Handler) Add(ctx *gin.Context) {
 func (h *Handler) UpdateNotes(ctx *gin.Context) {
 	mid, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mission ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	tid, err := strconv.Atoi(ctx.Param("tid"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		_ = ctx.Error(middleware.NewValidationError("tid", "must be a number"))
 		return
 	}
 
 	var body UpdateNotesRequest
 	if err := ctx.ShouldBindJSON(&body); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notes"})
+		_ = ctx.Error(middleware.ErrInvalidInput)
 		return
 	}
 
 	if err := h.Service._targetContext.UpdateNotes(ctx, uint(mid), uint(tid), body.Notes); err != nil {
-		if err.Error() == "mission is completed" || err.Error() == "target is completed" {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Target or Mission not found"})
-		}
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -128,17 +122,24 @@ func (h *Handler) UpdateNotes(ctx *gin.Context) {
 //	@Param			tid	path		int	true	"Target ID"
 //	@Success		200	{object}	models.Target
 //	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/missions/{id}/targets/{tid}/complete [post]
 func (h *Handler) MarkComplete(ctx *gin.Context) {
+	mid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
+		return
+	}
+
 	tid, err := strconv.Atoi(ctx.Param("tid"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		_ = ctx.Error(middleware.NewValidationError("tid", "must be a number"))
 		return
 	}
 
-	if err := h.Service._targetContext.MarkComplete(ctx, uint(tid)); err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+	if err := h.Service._targetContext.MarkComplete(ctx, uint(mid), uint(tid)); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -157,27 +158,24 @@ func (h *Handler) MarkComplete(ctx *gin.Context) {
 //	@Success		204	"No Content"
 //	@Failure		401	{object}	map[string]interface{}
 //	@Failure		403	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Router			/missions/{id}/targets/{tid} [delete]
 func (h *Handler) Delete(ctx *gin.Context) {
 	mid, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mission ID"})
+		_ = ctx.Error(middleware.NewValidationError("id", "must be a number"))
 		return
 	}
 
 	tid, err := strconv.Atoi(ctx.Param("tid"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		_ = ctx.Error(middleware.NewValidationError("tid", "must be a number"))
 		return
 	}
 
 	if err := h.Service._targetContext.DeleteByID(ctx, uint(mid), uint(tid)); err != nil {
-		if err.Error() == "cannot delete completed target" {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
-		}
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"testing"
 
+	"DevelopsToday/internal/controller/http/middleware"
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/repo/mocks"
 	"DevelopsToday/internal/services"
@@ -21,12 +22,13 @@ func setupTestRouter() (*gin.Engine, *Service) {
 	gin.SetMode(gin.TestMode)
 
 	store := mocks.NewRepository()
-	targetService := services.NewTarget(store.Target(), store.Mission())
+	targetService := services.NewTarget(store, nil)
 
 	service := NewImplService(targetService)
 	handler := &Handler{Service: service}
 
 	router := gin.New()
+	router.Use(middleware.GlobalErrorHandler())
 	v1 := router.Group("/v1")
 	targets := v1.Group("/missions/:id/targets")
 	{
@@ -157,7 +159,7 @@ func TestTargetController_UpdateNotes(t *testing.T) {
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("should fail for non-existing target", func(t *testing.T) {
@@ -269,7 +271,8 @@ func TestTargetController_Delete(t *testing.T) {
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "TARGET_COMPLETE")
 	})
 
 	t.Run("should fail for non-existing target", func(t *testing.T) {
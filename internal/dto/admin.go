@@ -0,0 +1,40 @@
+package dto
+
+// ChangeRoleRequest represents a role change of a user
+// @Description Role change request
+type ChangeRoleRequest struct {
+	// New role of the user
+	// @example "manager"
+	Role string `json:"role" binding:"required" example:"manager"`
+}
+
+// UnlockLoginRequest represents the optional body of a login unlock
+// @Description Login unlock request
+type UnlockLoginRequest struct {
+	// Client IP to unlock as well, for example the address of an office
+	// behind NAT
+	// @example "203.0.113.7"
+	IP string `json:"ip,omitempty" binding:"omitempty,ip" example:"203.0.113.7"`
+}
+
+// AuditLogQuery represents the query parameters for listing the audit trail
+// @Description Audit trail filters
+type AuditLogQuery struct {
+	PageQuery
+
+	// Only actions performed on this user
+	// @example 2
+	UserID *uint `form:"user_id" example:"2"`
+}
+
+// InvitationResponse represents a newly issued invitation code
+// @Description Invitation code for registration
+type InvitationResponse struct {
+	// Single-use code to pass as invite_code on registration
+	// @example "3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"
+	Code string `json:"code" example:"3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"`
+
+	// Time the code stops being accepted
+	// @example "2023-12-04T10:00:00Z"
+	ExpiresAt string `json:"expires_at" example:"2023-12-04T10:00:00Z"`
+}
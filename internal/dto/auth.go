@@ -3,33 +3,42 @@ package dto
 // RegisterRequest represents the registration request
 // @Description User registration request
 type RegisterRequest struct {
-	// Username for the new user account
+	// Username for the new user account, stored in lower case. It cannot
+	// contain "@" so it is never mistaken for an email on login.
 	// @example "john_doe"
-	Username string `json:"username" binding:"required,min=3,max=50" example:"john_doe"`
+	Username string `json:"username" binding:"required,min=3,max=50,excludes=@" example:"john_doe"`
 
-	// Email address for the new user account
+	// Email address for the new user account, stored in lower case
 	// @example "john.doe@example.com"
 	Email string `json:"email" binding:"required,email" example:"john.doe@example.com"`
 
-	// Password for the new user account (minimum 6 characters)
+	// Password for the new user account, it has to meet the password policy
 	// @example "securepassword123"
-	Password string `json:"password" binding:"required,min=6" example:"securepassword123"`
+	Password string `json:"password" binding:"required" example:"securepassword123"`
+
+	// Invitation code, required when registration is by invitation only
+	// @example "3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"
+	InviteCode string `json:"invite_code,omitempty" example:"3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"`
 
-	// Role for the user (optional, defaults to "spy")
-	// @example "spy"
-	Role string `json:"role,omitempty" example:"spy"`
+	// Name of the device the session is created for (optional)
+	// @example "John's laptop"
+	Device string `json:"device,omitempty" binding:"max=100" example:"John's laptop"`
 }
 
 // LoginRequest represents the login request
 // @Description User login request
 type LoginRequest struct {
-	// Username or email for authentication
+	// Username or email for authentication, case-insensitive
 	// @example "john_doe"
 	Username string `json:"username" binding:"required" example:"john_doe"`
 
 	// Password for authentication
 	// @example "securepassword123"
 	Password string `json:"password" binding:"required" example:"securepassword123"`
+
+	// Name of the device the session is created for (optional)
+	// @example "John's laptop"
+	Device string `json:"device,omitempty" binding:"max=100" example:"John's laptop"`
 }
 
 // RefreshRequest represents the refresh token request
@@ -40,6 +49,38 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
 
+// ChangePasswordRequest represents the password change request
+// @Description Password change of the current user
+type ChangePasswordRequest struct {
+	// Current password of the user
+	// @example "securepassword123"
+	CurrentPassword string `json:"current_password" binding:"required" example:"securepassword123"`
+
+	// New password, it has to meet the password policy
+	// @example "evenmoresecure456"
+	NewPassword string `json:"new_password" binding:"required" example:"evenmoresecure456"`
+}
+
+// ForgotPasswordRequest represents the request for a password reset link
+// @Description Password reset link request
+type ForgotPasswordRequest struct {
+	// Email address of the account
+	// @example "john.doe@example.com"
+	Email string `json:"email" binding:"required,email" example:"john.doe@example.com"`
+}
+
+// ResetPasswordRequest represents the password reset request
+// @Description Password reset with a token from the reset link
+type ResetPasswordRequest struct {
+	// Token from the password reset link
+	// @example "3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"
+	Token string `json:"token" binding:"required" example:"3f2a9c1e5b7d40a8b6c2e9f1d4a7b3c5"`
+
+	// New password, it has to meet the password policy
+	// @example "evenmoresecure456"
+	NewPassword string `json:"new_password" binding:"required" example:"evenmoresecure456"`
+}
+
 // AuthResponse represents the authentication response
 // @Description Authentication response with user data and tokens
 type AuthResponse struct {
@@ -71,8 +112,8 @@ type UserResponse struct {
 	Email string `json:"email" example:"john.doe@example.com"`
 
 	// Role of the user
-	// @example "spy"
-	Role string `json:"role" example:"spy"`
+	// @example "agent"
+	Role string `json:"role" example:"agent"`
 
 	// Account creation timestamp
 	// @example "2023-12-01T10:00:00Z"
@@ -82,3 +123,39 @@ type UserResponse struct {
 	// @example "2023-12-01T10:00:00Z"
 	UpdatedAt string `json:"updated_at" example:"2023-12-01T10:00:00Z"`
 }
+
+// SessionResponse represents a logged in device
+// @Description Active session of the current user
+type SessionResponse struct {
+	// Session identifier
+	// @example "9f86d081884c7d659a2feaa0c55ad015"
+	ID string `json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+
+	// Device name given at login
+	// @example "John's laptop"
+	Device string `json:"device" example:"John's laptop"`
+
+	// User agent of the client that logged in
+	// @example "Mozilla/5.0 (X11; Linux x86_64)"
+	UserAgent string `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+
+	// IP address of the client that logged in
+	// @example "203.0.113.7"
+	IP string `json:"ip" example:"203.0.113.7"`
+
+	// Whether the session belongs to the token used for this request
+	// @example true
+	Current bool `json:"current" example:"true"`
+
+	// Login timestamp
+	// @example "2023-12-01T10:00:00Z"
+	CreatedAt string `json:"created_at" example:"2023-12-01T10:00:00Z"`
+
+	// Last token refresh timestamp
+	// @example "2023-12-01T10:00:00Z"
+	LastUsedAt string `json:"last_used_at" example:"2023-12-01T10:00:00Z"`
+
+	// Time the session expires unless it is refreshed
+	// @example "2023-12-08T10:00:00Z"
+	ExpiresAt string `json:"expires_at" example:"2023-12-08T10:00:00Z"`
+}
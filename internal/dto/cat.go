@@ -0,0 +1,31 @@
+package dto
+
+// CatListQuery represents the query parameters for listing cats
+// @Description Cat listing filters
+type CatListQuery struct {
+	PageQuery
+
+	// Filter by breed (case-insensitive)
+	// @example "Bengal"
+	Breed string `form:"breed" example:"Bengal"`
+
+	// Filter by name prefix (case-insensitive)
+	// @example "Whi"
+	Name string `form:"name" example:"Whi"`
+
+	// Minimum years of experience
+	// @example 2
+	MinExperience *int `form:"min_experience" binding:"omitempty,min=0" example:"2"`
+
+	// Maximum years of experience
+	// @example 10
+	MaxExperience *int `form:"max_experience" binding:"omitempty,min=0" example:"10"`
+
+	// Minimum salary
+	// @example 500
+	MinSalary *float64 `form:"min_salary" binding:"omitempty,min=0" example:"500"`
+
+	// Maximum salary
+	// @example 2000
+	MaxSalary *float64 `form:"max_salary" binding:"omitempty,min=0" example:"2000"`
+}
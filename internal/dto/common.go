@@ -47,6 +47,37 @@ type PaginationMeta struct {
 	TotalPages int `json:"total_pages" example:"10"`
 }
 
+// NewPaginationMeta builds pagination metadata for the given page
+func NewPaginationMeta(page, limit int, total int64) PaginationMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+// PageQuery represents common paging and sorting query parameters
+// @Description Paging and sorting query parameters
+type PageQuery struct {
+	// Page number, starting from 1
+	// @example 1
+	Page int `form:"page" binding:"omitempty,min=1" example:"1"`
+
+	// Number of items per page (max 100)
+	// @example 10
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100" example:"10"`
+
+	// Comma separated sort fields, prefix with "-" for descending order
+	// @example "-salary,name"
+	Sort string `form:"sort" example:"-salary,name"`
+}
+
 // PaginatedResponse represents a paginated response
 // @Description Paginated response structure
 type PaginatedResponse struct {
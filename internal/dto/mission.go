@@ -0,0 +1,27 @@
+package dto
+
+// MissionListQuery represents the query parameters for listing missions
+// @Description Mission listing filters
+type MissionListQuery struct {
+	// Filter by completion status
+	// @example false
+	Complete *bool `form:"complete" example:"false"`
+
+	// Filter by assigned cat
+	// @example 1
+	CatID *uint `form:"cat_id" example:"1"`
+
+	// Include the mission targets in the response (defaults to true)
+	// @example true
+	IncludeTargets *bool `form:"include_targets" example:"true"`
+
+	// Filter by country of at least one target (case-insensitive)
+	// @example "France"
+	TargetCountry string `form:"target_country" example:"France"`
+
+	PageQuery
+
+	// Only return missions without an assigned cat
+	// @example true
+	Unassigned bool `form:"unassigned" example:"true"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AuditLog records an administrative action on a user account
+// @Description Audit trail entry
+type AuditLog struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// ActorID is the user who performed the action
+	ActorID uint `json:"actor_id" gorm:"index;not null"`
+	// Action is a dotted name such as "user.role_changed"
+	Action string `json:"action" gorm:"index;not null"`
+	// SubjectID is the user the action was performed on, if any
+	SubjectID *uint     `json:"subject_id,omitempty" gorm:"index"`
+	Details   string    `json:"details"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -7,4 +7,6 @@ type Mission struct {
 	Targets  []Target `gorm:"foreignKey:MissionID;constraint:OnDelete:CASCADE;" json:"targets"`
 	ID       uint     `gorm:"primaryKey" json:"id"`
 	Complete bool     `json:"complete"`
+	// AutoComplete completes the mission as soon as its last target is completed
+	AutoComplete bool `gorm:"not null;default:false" json:"auto_complete"`
 }
@@ -1,27 +1,46 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// User represents a user in the system
+// User represents a user in the system. Usernames and emails are stored
+// normalized and are unique regardless of case.
 type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
+	Username  string         `json:"username" gorm:"not null;uniqueIndex:idx_users_username_lower,expression:lower(username)"`
+	Email     string         `json:"email" gorm:"not null;uniqueIndex:idx_users_email_lower,expression:lower(email)"`
 	Password  string         `json:"-" gorm:"not null"`
-	Role      string         `json:"role" gorm:"default:'user'"`
+	Role      string         `json:"role" gorm:"default:'agent'"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
-// HashPassword hashes the user's password
+// NormalizeLogin case-folds and trims a username or email. Lookups must
+// normalize too.
+func NormalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+// Normalize normalizes the username and email
+func (u *User) Normalize() {
+	u.Username = NormalizeLogin(u.Username)
+	u.Email = NormalizeLogin(u.Email)
+}
+
+// HashPassword hashes the user's password with the default cost
 func (u *User) HashPassword() error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	return u.HashPasswordWithCost(bcrypt.DefaultCost)
+}
+
+// HashPasswordWithCost hashes the user's password with the given bcrypt cost
+func (u *User) HashPasswordWithCost(cost int) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), cost)
 	if err != nil {
 		return err
 	}
@@ -29,13 +48,32 @@ func (u *User) HashPassword() error {
 	return nil
 }
 
+// PasswordHashed reports whether Password already holds a bcrypt hash
+func (u *User) PasswordHashed() bool {
+	_, err := bcrypt.Cost([]byte(u.Password))
+	return err == nil
+}
+
+// NeedsRehash reports whether the stored hash was made with a cost other
+// than cost
+func (u *User) NeedsRehash(cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(u.Password))
+	return err != nil || hashCost != cost
+}
+
 // CheckPassword checks if the provided password matches the user's password
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
 
-// BeforeCreate is a GORM hook that runs before creating a user
+// BeforeCreate is a GORM hook that runs before creating a user. Passwords
+// that are already hashed, e.g. by the password policy or in imported users,
+// are kept as they are.
 func (u *User) BeforeCreate(tx *gorm.DB) error {
+	u.Normalize()
+	if u.PasswordHashed() {
+		return nil
+	}
 	return u.HashPassword()
 }
@@ -0,0 +1,34 @@
+package repo
+
+import "errors"
+
+// ErrNotFound is matched by every entity specific not found error, so callers
+// that do not care about the entity can use errors.Is(err, repo.ErrNotFound)
+var ErrNotFound = errors.New("record not found")
+
+// Errors returned by repositories when an entity does not exist
+var (
+	ErrCatNotFound     error = &notFoundError{entity: "cat"}
+	ErrMissionNotFound error = &notFoundError{entity: "mission"}
+	ErrTargetNotFound  error = &notFoundError{entity: "target"}
+	ErrUserNotFound    error = &notFoundError{entity: "user"}
+)
+
+// Errors returned by repositories when a business invariant does not hold
+var (
+	ErrMissionComplete = errors.New("mission is already completed")
+	ErrCatBusy         = errors.New("cat is already assigned to another mission")
+	ErrPasswordChanged = errors.New("password was changed meanwhile")
+)
+
+type notFoundError struct {
+	entity string
+}
+
+func (e *notFoundError) Error() string {
+	return e.entity + " not found"
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
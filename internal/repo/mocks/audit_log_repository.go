@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+)
+
+type MockAuditLogRepository struct {
+	store *Mocks
+}
+
+func (r *MockAuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	defer r.store.lock()()
+
+	entry.ID = r.store.nextAuditLogID
+	r.store.nextAuditLogID++
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	r.store.auditLogs = append(r.store.auditLogs, *entry)
+	return nil
+}
+
+func (r *MockAuditLogRepository) FindPage(ctx context.Context, filter repo.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	defer r.store.rlock()()
+
+	// Записи додаються по порядку, тому найновіші — в кінці
+	entries := make([]models.AuditLog, 0, len(r.store.auditLogs))
+	for i := len(r.store.auditLogs) - 1; i >= 0; i-- {
+		entry := r.store.auditLogs[i]
+		if filter.SubjectID != nil && (entry.SubjectID == nil || *entry.SubjectID != *filter.SubjectID) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	page, total := paginate(entries, filter.Pagination)
+	return page, total, nil
+}
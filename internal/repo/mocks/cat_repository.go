@@ -3,10 +3,10 @@ package mocks
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"DevelopsToday/internal/models"
-
-	"gorm.io/gorm"
+	"DevelopsToday/internal/repo"
 )
 
 type MockCatRepository struct {
@@ -14,8 +14,7 @@ type MockCatRepository struct {
 }
 
 func (r *MockCatRepository) Create(ctx context.Context, cat *models.Cat) error {
-	r.store.mutex.Lock()
-	defer r.store.mutex.Unlock()
+	defer r.store.lock()()
 
 	if cat.ID == 0 {
 		cat.ID = r.store.nextCatID
@@ -41,8 +40,7 @@ func (r *MockCatRepository) Create(ctx context.Context, cat *models.Cat) error {
 }
 
 func (r *MockCatRepository) FindAll(ctx context.Context) ([]models.Cat, error) {
-	r.store.mutex.RLock()
-	defer r.store.mutex.RUnlock()
+	defer r.store.rlock()()
 
 	cats := make([]models.Cat, 0, len(r.store.cats))
 	for _, cat := range r.store.cats {
@@ -52,13 +50,28 @@ func (r *MockCatRepository) FindAll(ctx context.Context) ([]models.Cat, error) {
 	return cats, nil
 }
 
+func (r *MockCatRepository) FindPage(ctx context.Context, filter repo.CatFilter) ([]models.Cat, int64, error) {
+	defer r.store.rlock()()
+
+	cats := make([]models.Cat, 0, len(r.store.cats))
+	for _, cat := range r.store.cats {
+		if !matchCat(cat, &filter) {
+			continue
+		}
+		cats = append(cats, *cat)
+	}
+
+	sortByFields(cats, filter.Sort, catField)
+	page, total := paginate(cats, filter.Pagination)
+	return page, total, nil
+}
+
 func (r *MockCatRepository) FindByID(ctx context.Context, id uint) (*models.Cat, error) {
-	r.store.mutex.RLock()
-	defer r.store.mutex.RUnlock()
+	defer r.store.rlock()()
 
 	cat, exists := r.store.cats[id]
 	if !exists {
-		return nil, gorm.ErrRecordNotFound
+		return nil, repo.ErrCatNotFound
 	}
 
 	result := &models.Cat{
@@ -73,12 +86,11 @@ func (r *MockCatRepository) FindByID(ctx context.Context, id uint) (*models.Cat,
 }
 
 func (r *MockCatRepository) UpdateSalary(ctx context.Context, id uint, salary float64) error {
-	r.store.mutex.Lock()
-	defer r.store.mutex.Unlock()
+	defer r.store.lock()()
 
 	cat, exists := r.store.cats[id]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrCatNotFound
 	}
 
 	cat.Salary = salary
@@ -86,13 +98,51 @@ func (r *MockCatRepository) UpdateSalary(ctx context.Context, id uint, salary fl
 }
 
 func (r *MockCatRepository) DeleteByID(ctx context.Context, id uint) error {
-	r.store.mutex.Lock()
-	defer r.store.mutex.Unlock()
+	defer r.store.lock()()
 
 	if _, exists := r.store.cats[id]; !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrCatNotFound
 	}
 
 	delete(r.store.cats, id)
 	return nil
 }
+
+// matchCat перевіряє, чи кіт відповідає фільтру
+func matchCat(cat *models.Cat, filter *repo.CatFilter) bool {
+	if filter.Breed != "" && !strings.EqualFold(cat.Breed, filter.Breed) {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(cat.Name), strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.MinExperience != nil && cat.Experience < *filter.MinExperience {
+		return false
+	}
+	if filter.MaxExperience != nil && cat.Experience > *filter.MaxExperience {
+		return false
+	}
+	if filter.MinSalary != nil && cat.Salary < *filter.MinSalary {
+		return false
+	}
+	if filter.MaxSalary != nil && cat.Salary > *filter.MaxSalary {
+		return false
+	}
+	return true
+}
+
+// catField повертає значення поля кота для сортування
+func catField(cat *models.Cat, field string) any {
+	switch field {
+	case "name":
+		return cat.Name
+	case "breed":
+		return cat.Breed
+	case "experience":
+		return cat.Experience
+	case "salary":
+		return cat.Salary
+	default:
+		return cat.ID
+	}
+}
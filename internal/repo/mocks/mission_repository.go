@@ -2,10 +2,10 @@ package mocks
 
 import (
 	"context"
+	"strings"
 
 	"DevelopsToday/internal/models"
-
-	"gorm.io/gorm"
+	"DevelopsToday/internal/repo"
 )
 
 type MockMissionRepository struct {
@@ -13,8 +13,7 @@ type MockMissionRepository struct {
 }
 
 func (m *MockMissionRepository) Create(ctx context.Context, mission *models.Mission) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	if mission.ID == 0 {
 		mission.ID = m.store.nextMissionID
@@ -22,10 +21,11 @@ func (m *MockMissionRepository) Create(ctx context.Context, mission *models.Miss
 	}
 
 	newMission := &models.Mission{
-		ID:       mission.ID,
-		CatID:    mission.CatID,
-		Complete: mission.Complete,
-		Targets:  make([]models.Target, len(mission.Targets)),
+		ID:           mission.ID,
+		CatID:        mission.CatID,
+		Complete:     mission.Complete,
+		AutoComplete: mission.AutoComplete,
+		Targets:      make([]models.Target, len(mission.Targets)),
 	}
 
 	for i, target := range mission.Targets {
@@ -55,8 +55,7 @@ func (m *MockMissionRepository) Create(ctx context.Context, mission *models.Miss
 }
 
 func (m *MockMissionRepository) FindAll(ctx context.Context) ([]models.Mission, error) {
-	m.store.mutex.RLock()
-	defer m.store.mutex.RUnlock()
+	defer m.store.rlock()()
 
 	missions := make([]models.Mission, 0, len(m.store.missions))
 	for _, mission := range m.store.missions {
@@ -67,30 +66,59 @@ func (m *MockMissionRepository) FindAll(ctx context.Context) ([]models.Mission,
 	return missions, nil
 }
 
+func (m *MockMissionRepository) FindPage(ctx context.Context, filter repo.MissionFilter) ([]models.Mission, int64, error) {
+	defer m.store.rlock()()
+
+	missions := make([]models.Mission, 0, len(m.store.missions))
+	for _, mission := range m.store.missions {
+		missionCopy := m.copyMissionWithTargets(mission)
+		if !matchMission(missionCopy, &filter) {
+			continue
+		}
+		if filter.SkipTargets {
+			missionCopy.Targets = nil
+		}
+		missions = append(missions, *missionCopy)
+	}
+
+	sortByFields(missions, filter.Sort, missionField)
+	page, total := paginate(missions, filter.Pagination)
+	return page, total, nil
+}
+
 func (m *MockMissionRepository) FindByID(ctx context.Context, id uint) (*models.Mission, error) {
-	m.store.mutex.RLock()
-	defer m.store.mutex.RUnlock()
+	defer m.store.rlock()()
 
 	mission, exists := m.store.missions[id]
 	if !exists {
-		return nil, gorm.ErrRecordNotFound
+		return nil, repo.ErrMissionNotFound
 	}
 
 	return m.copyMissionWithTargets(mission), nil
 }
 
 func (m *MockMissionRepository) AssignCat(ctx context.Context, missionID, catID uint) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
+
+	// Перевіряємо, чи існує кіт
+	if _, catExists := m.store.cats[catID]; !catExists {
+		return repo.ErrCatNotFound
+	}
 
 	mission, exists := m.store.missions[missionID]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrMissionNotFound
 	}
 
-	// Перевіряємо, чи існує кіт
-	if _, catExists := m.store.cats[catID]; !catExists {
-		return gorm.ErrRecordNotFound
+	if mission.Complete {
+		return repo.ErrMissionComplete
+	}
+
+	// Кіт може мати лише одну активну місію
+	for id, other := range m.store.missions {
+		if id != missionID && !other.Complete && other.CatID != nil && *other.CatID == catID {
+			return repo.ErrCatBusy
+		}
 	}
 
 	mission.CatID = &catID
@@ -98,12 +126,11 @@ func (m *MockMissionRepository) AssignCat(ctx context.Context, missionID, catID
 }
 
 func (m *MockMissionRepository) MarkComplete(ctx context.Context, id uint) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	mission, exists := m.store.missions[id]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrMissionNotFound
 	}
 
 	mission.Complete = true
@@ -111,12 +138,11 @@ func (m *MockMissionRepository) MarkComplete(ctx context.Context, id uint) error
 }
 
 func (m *MockMissionRepository) DeleteByID(ctx context.Context, id uint) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	mission, exists := m.store.missions[id]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrMissionNotFound
 	}
 
 	// Видаляємо всі цілі цієї місії
@@ -131,10 +157,11 @@ func (m *MockMissionRepository) DeleteByID(ctx context.Context, id uint) error {
 // copyMissionWithTargets створює повну копію місії з усіма цілями
 func (m *MockMissionRepository) copyMissionWithTargets(mission *models.Mission) *models.Mission {
 	result := &models.Mission{
-		ID:       mission.ID,
-		CatID:    mission.CatID,
-		Complete: mission.Complete,
-		Targets:  make([]models.Target, 0),
+		ID:           mission.ID,
+		CatID:        mission.CatID,
+		Complete:     mission.Complete,
+		AutoComplete: mission.AutoComplete,
+		Targets:      make([]models.Target, 0),
 	}
 
 	// Знаходимо всі цілі для цієї місії
@@ -154,3 +181,40 @@ func (m *MockMissionRepository) copyMissionWithTargets(mission *models.Mission)
 
 	return result
 }
+
+// matchMission перевіряє, чи місія відповідає фільтру
+func matchMission(mission *models.Mission, filter *repo.MissionFilter) bool {
+	if filter.Complete != nil && mission.Complete != *filter.Complete {
+		return false
+	}
+	if filter.CatID != nil && (mission.CatID == nil || *mission.CatID != *filter.CatID) {
+		return false
+	}
+	if filter.Unassigned && mission.CatID != nil {
+		return false
+	}
+	if filter.TargetCountry != "" {
+		for _, target := range mission.Targets {
+			if strings.EqualFold(target.Country, filter.TargetCountry) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// missionField повертає значення поля місії для сортування
+func missionField(mission *models.Mission, field string) any {
+	switch field {
+	case "cat_id":
+		if mission.CatID == nil {
+			return uint(0)
+		}
+		return *mission.CatID
+	case "complete":
+		return mission.Complete
+	default:
+		return mission.ID
+	}
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"sync"
 
 	"DevelopsToday/internal/models"
@@ -8,27 +9,49 @@ import (
 )
 
 type Mocks struct {
-	cats                  map[uint]*models.Cat
-	missions              map[uint]*models.Mission
-	targets               map[uint]*models.Target
-	mockCatRepository     *MockCatRepository
-	mockMissionRepository *MockMissionRepository
-	mockTargetRepository  *MockTargetRepository
-	mutex                 sync.RWMutex
-	nextCatID             uint
-	nextMissionID         uint
-	nextTargetID          uint
+	*mocksState
+	mockCatRepository      *MockCatRepository
+	mockMissionRepository  *MockMissionRepository
+	mockTargetRepository   *MockTargetRepository
+	mockUserRepository     *MockUserRepository
+	mockAuditLogRepository *MockAuditLogRepository
+	// inTx позначає сховище, передане у WithTx
+	inTx bool
 }
 
+// mocksState - дані сховища, спільні для Mocks та його транзакцій
+type mocksState struct {
+	cats      map[uint]*models.Cat
+	missions  map[uint]*models.Mission
+	targets   map[uint]*models.Target
+	users     map[uint]*models.User
+	auditLogs []models.AuditLog
+	mutex     sync.RWMutex
+	// txMutex утримується транзакцією весь час її виконання. Операції поза
+	// транзакцією чекають на її завершення, тож не бачать незафіксованих змін,
+	// а відкат не стирає їхніх записів.
+	txMutex        sync.RWMutex
+	nextCatID      uint
+	nextMissionID  uint
+	nextTargetID   uint
+	nextUserID     uint
+	nextAuditLogID uint
+}
+
+var _ repo.Store = (*Mocks)(nil)
+
 func NewRepository() *Mocks {
-	m := &Mocks{
-		cats:          make(map[uint]*models.Cat),
-		missions:      make(map[uint]*models.Mission),
-		targets:       make(map[uint]*models.Target),
-		nextCatID:     1,
-		nextMissionID: 1,
-		nextTargetID:  1,
-	}
+	m := &Mocks{mocksState: &mocksState{
+		cats:           make(map[uint]*models.Cat),
+		missions:       make(map[uint]*models.Mission),
+		targets:        make(map[uint]*models.Target),
+		users:          make(map[uint]*models.User),
+		nextCatID:      1,
+		nextMissionID:  1,
+		nextTargetID:   1,
+		nextUserID:     1,
+		nextAuditLogID: 1,
+	}}
 
 	// Додаємо початкові тестові дані
 	m.seedData()
@@ -117,8 +140,7 @@ func (m *Mocks) seedData() {
 
 // Допоміжні методи для тестування
 func (m *Mocks) AddCat(cat *models.Cat) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.lock()()
 	if cat.ID == 0 {
 		cat.ID = m.nextCatID
 		m.nextCatID++
@@ -127,8 +149,7 @@ func (m *Mocks) AddCat(cat *models.Cat) {
 }
 
 func (m *Mocks) AddMission(mission *models.Mission) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.lock()()
 	if mission.ID == 0 {
 		mission.ID = m.nextMissionID
 		m.nextMissionID++
@@ -137,8 +158,7 @@ func (m *Mocks) AddMission(mission *models.Mission) {
 }
 
 func (m *Mocks) AddTarget(target *models.Target) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.lock()()
 	if target.ID == 0 {
 		target.ID = m.nextTargetID
 		m.nextTargetID++
@@ -179,3 +199,148 @@ func (m *Mocks) Target() repo.TargetRepository {
 
 	return m.mockTargetRepository
 }
+
+func (m *Mocks) User() repo.UserRepository {
+	if m.mockUserRepository != nil {
+		return m.mockUserRepository
+	}
+
+	m.mockUserRepository = &MockUserRepository{
+		store: m,
+	}
+
+	return m.mockUserRepository
+}
+
+func (m *Mocks) AuditLog() repo.AuditLogRepository {
+	if m.mockAuditLogRepository != nil {
+		return m.mockAuditLogRepository
+	}
+
+	m.mockAuditLogRepository = &MockAuditLogRepository{
+		store: m,
+	}
+
+	return m.mockAuditLogRepository
+}
+
+// WithTx виконує fn як одну транзакцію: транзакції виконуються послідовно,
+// а у разі помилки стан сховища відновлюється зі знімка. Вкладений виклик
+// працює як точка збереження і відкочує лише власні зміни.
+func (m *Mocks) WithTx(ctx context.Context, fn func(tx repo.Store) error) error {
+	tx := m
+	if !m.inTx {
+		m.txMutex.Lock()
+		defer m.txMutex.Unlock()
+		tx = &Mocks{mocksState: m.mocksState, inTx: true}
+	}
+
+	snapshot := tx.snapshot()
+	if err := fn(tx); err != nil {
+		tx.restore(snapshot)
+		return err
+	}
+
+	return nil
+}
+
+// lock блокує сховище для запису і повертає функцію розблокування
+func (m *Mocks) lock() func() {
+	m.enter()
+	m.mutex.Lock()
+	return func() {
+		m.mutex.Unlock()
+		m.leave()
+	}
+}
+
+// rlock блокує сховище для читання і повертає функцію розблокування
+func (m *Mocks) rlock() func() {
+	m.enter()
+	m.mutex.RLock()
+	return func() {
+		m.mutex.RUnlock()
+		m.leave()
+	}
+}
+
+// enter чекає на завершення поточної транзакції, якщо виклик зроблено поза нею
+func (m *Mocks) enter() {
+	if !m.inTx {
+		m.txMutex.RLock()
+	}
+}
+
+func (m *Mocks) leave() {
+	if !m.inTx {
+		m.txMutex.RUnlock()
+	}
+}
+
+// mocksSnapshot зберігає копію стану сховища
+type mocksSnapshot struct {
+	cats           map[uint]*models.Cat
+	missions       map[uint]*models.Mission
+	targets        map[uint]*models.Target
+	users          map[uint]*models.User
+	auditLogs      []models.AuditLog
+	nextCatID      uint
+	nextMissionID  uint
+	nextTargetID   uint
+	nextUserID     uint
+	nextAuditLogID uint
+}
+
+func (m *Mocks) snapshot() *mocksSnapshot {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	s := &mocksSnapshot{
+		cats:           make(map[uint]*models.Cat, len(m.cats)),
+		missions:       make(map[uint]*models.Mission, len(m.missions)),
+		targets:        make(map[uint]*models.Target, len(m.targets)),
+		users:          make(map[uint]*models.User, len(m.users)),
+		nextCatID:      m.nextCatID,
+		nextMissionID:  m.nextMissionID,
+		nextTargetID:   m.nextTargetID,
+		nextUserID:     m.nextUserID,
+		auditLogs:      append([]models.AuditLog(nil), m.auditLogs...),
+		nextAuditLogID: m.nextAuditLogID,
+	}
+
+	for id, cat := range m.cats {
+		catCopy := *cat
+		s.cats[id] = &catCopy
+	}
+	for id, mission := range m.missions {
+		missionCopy := *mission
+		missionCopy.Targets = append([]models.Target(nil), mission.Targets...)
+		s.missions[id] = &missionCopy
+	}
+	for id, target := range m.targets {
+		targetCopy := *target
+		s.targets[id] = &targetCopy
+	}
+	for id, user := range m.users {
+		userCopy := *user
+		s.users[id] = &userCopy
+	}
+
+	return s
+}
+
+func (m *Mocks) restore(s *mocksSnapshot) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cats = s.cats
+	m.missions = s.missions
+	m.targets = s.targets
+	m.users = s.users
+	m.nextCatID = s.nextCatID
+	m.nextMissionID = s.nextMissionID
+	m.nextTargetID = s.nextTargetID
+	m.nextUserID = s.nextUserID
+	m.auditLogs = s.auditLogs
+	m.nextAuditLogID = s.nextAuditLogID
+}
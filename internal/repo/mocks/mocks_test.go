@@ -2,11 +2,12 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"DevelopsToday/internal/models"
-
-	"gorm.io/gorm"
+	"DevelopsToday/internal/repo"
 )
 
 func TestMockCatRepository(t *testing.T) {
@@ -36,8 +37,8 @@ func TestMockCatRepository(t *testing.T) {
 
 	t.Run("FindByID should return error for non-existing cat", func(t *testing.T) {
 		_, err := catRepo.FindByID(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 
@@ -90,8 +91,8 @@ func TestMockCatRepository(t *testing.T) {
 		}
 
 		_, err = catRepo.FindByID(ctx, 1)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 }
@@ -246,7 +247,7 @@ func TestMockTargetRepository(t *testing.T) {
 	})
 
 	t.Run("MarkComplete should mark target as complete", func(t *testing.T) {
-		err := targetRepo.MarkComplete(ctx, 1)
+		_, err := targetRepo.MarkComplete(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -270,3 +271,112 @@ func TestMockTargetRepository(t *testing.T) {
 		}
 	})
 }
+
+func TestMocksWithTx(t *testing.T) {
+	store := NewRepository()
+	ctx := context.Background()
+
+	t.Run("WithTx should commit changes when fn succeeds", func(t *testing.T) {
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			return tx.Cat().UpdateSalary(ctx, 1, 2000)
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cat, _ := store.Cat().FindByID(ctx, 1)
+		if cat.Salary != 2000 {
+			t.Fatalf("Expected salary 2000, got %f", cat.Salary)
+		}
+	})
+
+	t.Run("WithTx should roll back changes when fn fails", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			if err := tx.Cat().UpdateSalary(ctx, 2, 5000); err != nil {
+				return err
+			}
+			if err := tx.Mission().DeleteByID(ctx, 4); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Expected errBoom, got %v", err)
+		}
+
+		cat, _ := store.Cat().FindByID(ctx, 2)
+		if cat.Salary != 800 {
+			t.Fatalf("Expected salary to be rolled back to 800, got %f", cat.Salary)
+		}
+		mission, err := store.Mission().FindByID(ctx, 4)
+		if err != nil {
+			t.Fatalf("Expected mission 4 to be restored, got %v", err)
+		}
+		if len(mission.Targets) != 1 {
+			t.Fatalf("Expected 1 target, got %d", len(mission.Targets))
+		}
+	})
+
+	t.Run("WithTx rollback should keep writes made outside the transaction", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		written := make(chan error, 1)
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			go func() {
+				written <- store.Cat().UpdateSalary(ctx, 3, 3000)
+			}()
+			if err := tx.Cat().UpdateSalary(ctx, 4, 4000); err != nil {
+				return err
+			}
+			// Дає конкурентному запису шанс втрутитися в транзакцію
+			time.Sleep(10 * time.Millisecond)
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Expected errBoom, got %v", err)
+		}
+		if err := <-written; err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cat, _ := store.Cat().FindByID(ctx, 3)
+		if cat.Salary != 3000 {
+			t.Fatalf("Expected the outside write to be kept, got salary %f", cat.Salary)
+		}
+		cat, _ = store.Cat().FindByID(ctx, 4)
+		if cat.Salary != 900 {
+			t.Fatalf("Expected salary to be rolled back to 900, got %f", cat.Salary)
+		}
+	})
+
+	t.Run("a nested WithTx should roll back only its own changes", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			if err := tx.Cat().UpdateSalary(ctx, 5, 5000); err != nil {
+				return err
+			}
+			nested := tx.WithTx(ctx, func(tx repo.Store) error {
+				if err := tx.Cat().UpdateSalary(ctx, 1, 9000); err != nil {
+					return err
+				}
+				return errBoom
+			})
+			if !errors.Is(nested, errBoom) {
+				t.Errorf("Expected errBoom, got %v", nested)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cat, _ := store.Cat().FindByID(ctx, 5)
+		if cat.Salary != 5000 {
+			t.Fatalf("Expected salary 5000, got %f", cat.Salary)
+		}
+		cat, _ = store.Cat().FindByID(ctx, 1)
+		if cat.Salary != 2000 {
+			t.Fatalf("Expected the nested change to be rolled back to 2000, got %f", cat.Salary)
+		}
+	})
+}
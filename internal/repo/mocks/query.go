@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"cmp"
+	"slices"
+
+	"DevelopsToday/internal/repo"
+)
+
+// sortByFields сортує елементи за полями, а при рівності — за id
+func sortByFields[T any](items []T, fields []repo.SortField, value func(*T, string) any) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		for _, f := range fields {
+			c := compareValues(value(&a, f.Field), value(&b, f.Field))
+			if f.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return compareValues(value(&a, "id"), value(&b, "id"))
+	})
+}
+
+func compareValues(a, b any) int {
+	switch av := a.(type) {
+	case string:
+		return cmp.Compare(av, b.(string))
+	case int:
+		return cmp.Compare(av, b.(int))
+	case uint:
+		return cmp.Compare(av, b.(uint))
+	case float64:
+		return cmp.Compare(av, b.(float64))
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		default:
+			return 1
+		}
+	default:
+		return 0
+	}
+}
+
+// paginate повертає сторінку елементів та загальну кількість
+func paginate[T any](items []T, p repo.Pagination) ([]T, int64) {
+	p = p.Normalize()
+	total := int64(len(items))
+
+	start := p.Offset()
+	if start >= len(items) {
+		return []T{}, total
+	}
+	end := start + p.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], total
+}
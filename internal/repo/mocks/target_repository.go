@@ -4,8 +4,7 @@ import (
 	"context"
 
 	"DevelopsToday/internal/models"
-
-	"gorm.io/gorm"
+	"DevelopsToday/internal/repo"
 )
 
 type MockTargetRepository struct {
@@ -13,13 +12,12 @@ type MockTargetRepository struct {
 }
 
 func (m *MockTargetRepository) AddToMission(ctx context.Context, missionID uint, target *models.Target) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	// Перевіряємо, чи існує місія
 	mission, exists := m.store.missions[missionID]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrMissionNotFound
 	}
 
 	// Присвоюємо ID цілі, якщо його немає
@@ -52,12 +50,11 @@ func (m *MockTargetRepository) AddToMission(ctx context.Context, missionID uint,
 }
 
 func (m *MockTargetRepository) UpdateNotes(ctx context.Context, targetID uint, notes string) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	target, exists := m.store.targets[targetID]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrTargetNotFound
 	}
 
 	target.Notes = notes
@@ -75,37 +72,53 @@ func (m *MockTargetRepository) UpdateNotes(ctx context.Context, targetID uint, n
 	return nil
 }
 
-func (m *MockTargetRepository) MarkComplete(ctx context.Context, targetID uint) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+func (m *MockTargetRepository) MarkComplete(ctx context.Context, targetID uint) (*repo.TargetCompletion, error) {
+	defer m.store.lock()()
 
 	target, exists := m.store.targets[targetID]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return nil, repo.ErrTargetNotFound
 	}
 
 	target.Complete = true
+	result := &repo.TargetCompletion{MissionID: target.MissionID}
+
+	mission, missionExists := m.store.missions[target.MissionID]
+	if !missionExists {
+		return result, nil
+	}
+	result.CatID = mission.CatID
 
 	// Також оновлюємо в місії
-	if mission, missionExists := m.store.missions[target.MissionID]; missionExists {
-		for i, missionTarget := range mission.Targets {
-			if missionTarget.ID == targetID {
-				mission.Targets[i].Complete = true
-				break
-			}
+	for i, missionTarget := range mission.Targets {
+		if missionTarget.ID == targetID {
+			mission.Targets[i].Complete = true
+			break
 		}
 	}
 
-	return nil
+	if !mission.AutoComplete || mission.Complete {
+		return result, nil
+	}
+
+	// Завершуємо місію, якщо це була остання відкрита ціль
+	for _, t := range m.store.targets {
+		if t.MissionID == mission.ID && !t.Complete {
+			return result, nil
+		}
+	}
+	mission.Complete = true
+	result.MissionCompleted = true
+
+	return result, nil
 }
 
 func (m *MockTargetRepository) DeleteByID(ctx context.Context, id uint) error {
-	m.store.mutex.Lock()
-	defer m.store.mutex.Unlock()
+	defer m.store.lock()()
 
 	target, exists := m.store.targets[id]
 	if !exists {
-		return gorm.ErrRecordNotFound
+		return repo.ErrTargetNotFound
 	}
 
 	// Видаляємо з місії
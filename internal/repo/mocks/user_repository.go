@@ -0,0 +1,157 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+)
+
+type MockUserRepository struct {
+	store *Mocks
+}
+
+func (r *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	defer r.store.lock()()
+
+	// Імітуємо регістронезалежні унікальні індекси
+	for _, existing := range r.store.users {
+		if strings.EqualFold(existing.Username, models.NormalizeLogin(user.Username)) ||
+			strings.EqualFold(existing.Email, models.NormalizeLogin(user.Email)) {
+			return errors.New("user with this username or email already exists")
+		}
+	}
+
+	// Імітуємо GORM хук BeforeCreate
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	if user.ID == 0 {
+		user.ID = r.store.nextUserID
+		r.store.nextUserID++
+	}
+
+	newUser := *user
+	r.store.users[user.ID] = &newUser
+
+	return nil
+}
+
+func (r *MockUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	defer r.store.rlock()()
+
+	user, exists := r.store.users[id]
+	if !exists {
+		return nil, repo.ErrUserNotFound
+	}
+
+	result := *user
+	return &result, nil
+}
+
+func (r *MockUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	username = models.NormalizeLogin(username)
+	return r.findBy(func(u *models.User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+func (r *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	email = models.NormalizeLogin(email)
+	return r.findBy(func(u *models.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (r *MockUserRepository) FindByLogin(ctx context.Context, login string) (*models.User, error) {
+	login = models.NormalizeLogin(login)
+	return r.findBy(func(u *models.User) bool {
+		return strings.EqualFold(u.Username, login) || strings.EqualFold(u.Email, login)
+	})
+}
+
+func (r *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	defer r.store.lock()()
+
+	if _, exists := r.store.users[user.ID]; !exists {
+		return repo.ErrUserNotFound
+	}
+
+	updated := *user
+	r.store.users[user.ID] = &updated
+	return nil
+}
+
+func (r *MockUserRepository) UpdatePassword(ctx context.Context, id uint, current, hash string) error {
+	defer r.store.lock()()
+
+	user, exists := r.store.users[id]
+	if !exists {
+		return repo.ErrUserNotFound
+	}
+	if user.Password != current {
+		return repo.ErrPasswordChanged
+	}
+
+	updated := *user
+	updated.Password = hash
+	r.store.users[id] = &updated
+	return nil
+}
+
+func (r *MockUserRepository) DeleteByID(ctx context.Context, id uint) error {
+	defer r.store.lock()()
+
+	if _, exists := r.store.users[id]; !exists {
+		return repo.ErrUserNotFound
+	}
+
+	delete(r.store.users, id)
+	return nil
+}
+
+func (r *MockUserRepository) FindAll(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	defer r.store.rlock()()
+
+	users := make([]*models.User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		userCopy := *user
+		users = append(users, &userCopy)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	if offset >= len(users) {
+		return []*models.User{}, nil
+	}
+	users = users[offset:]
+	if limit > 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (r *MockUserRepository) FindPage(ctx context.Context, page repo.Pagination) ([]models.User, int64, error) {
+	defer r.store.rlock()()
+
+	users := make([]models.User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	result, total := paginate(users, page)
+	return result, total, nil
+}
+
+// findBy повертає копію першого користувача, що відповідає умові
+func (r *MockUserRepository) findBy(match func(*models.User) bool) (*models.User, error) {
+	defer r.store.rlock()()
+
+	for _, user := range r.store.users {
+		if match(user) {
+			result := *user
+			return &result, nil
+		}
+	}
+	return nil, repo.ErrUserNotFound
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+)
+
+type AuditLogRepository struct {
+	store *Repository
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.store.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *AuditLogRepository) FindPage(ctx context.Context, filter repo.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	query := r.store.db.WithContext(ctx).Model(&models.AuditLog{})
+
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page := filter.Pagination.Normalize()
+	var entries []models.AuditLog
+	err := query.Order("id DESC").
+		Limit(page.Limit).
+		Offset(page.Offset()).
+		Find(&entries).Error
+	return entries, total, err
+}
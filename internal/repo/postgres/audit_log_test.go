@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"DevelopsToday/internal/models"
+	dbrepo "DevelopsToday/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &AuditLogRepository{store: &Repository{db: db}}
+	ctx := context.Background()
+
+	subject := uint(7)
+	other := uint(8)
+	for _, entry := range []*models.AuditLog{
+		{ActorID: 1, Action: "user.role_changed", SubjectID: &subject, Details: "role agent -> manager"},
+		{ActorID: 1, Action: "invitation.created"},
+		{ActorID: 1, Action: "user.deleted", SubjectID: &other},
+		{ActorID: 2, Action: "user.tokens_revoked", SubjectID: &subject},
+	} {
+		assert.NoError(t, repo.Create(ctx, entry))
+		assert.NotZero(t, entry.ID)
+	}
+
+	t.Run("FindPage should return the newest entries first", func(t *testing.T) {
+		entries, total, err := repo.FindPage(ctx, dbrepo.AuditLogFilter{Pagination: dbrepo.Pagination{Page: 1, Limit: 2}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "user.tokens_revoked", entries[0].Action)
+		assert.Equal(t, "user.deleted", entries[1].Action)
+	})
+
+	t.Run("FindPage should filter by subject", func(t *testing.T) {
+		entries, total, err := repo.FindPage(ctx, dbrepo.AuditLogFilter{SubjectID: &subject})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, entry := range entries {
+			assert.Equal(t, subject, *entry.SubjectID)
+		}
+	})
+}
+
+func TestUserRepositoryFindPage(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &UserRepository{store: &Repository{db: db}}
+	ctx := context.Background()
+
+	for _, name := range []string{"alpha", "bravo", "charlie"} {
+		assert.NoError(t, repo.Create(ctx, &models.User{Username: name, Email: name + "@spycats.com", Password: "secret"}))
+	}
+
+	users, total, err := repo.FindPage(ctx, dbrepo.Pagination{Page: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "charlie", users[0].Username)
+	assert.Equal(t, "agent", users[0].Role)
+}
@@ -4,8 +4,7 @@ import (
 	"context"
 
 	"DevelopsToday/internal/models"
-
-	"gorm.io/gorm"
+	"DevelopsToday/internal/repo"
 )
 
 type CatRepository struct {
@@ -22,11 +21,47 @@ func (r *CatRepository) FindAll(ctx context.Context) ([]models.Cat, error) {
 	return cats, err
 }
 
+func (r *CatRepository) FindPage(ctx context.Context, filter repo.CatFilter) ([]models.Cat, int64, error) {
+	query := r.store.db.WithContext(ctx).Model(&models.Cat{})
+
+	if filter.Breed != "" {
+		query = query.Where("LOWER(breed) = LOWER(?)", filter.Breed)
+	}
+	if filter.NamePrefix != "" {
+		query = query.Where("LOWER(name) LIKE LOWER(?) ESCAPE '\\'", escapeLike(filter.NamePrefix)+"%")
+	}
+	if filter.MinExperience != nil {
+		query = query.Where("experience >= ?", *filter.MinExperience)
+	}
+	if filter.MaxExperience != nil {
+		query = query.Where("experience <= ?", *filter.MaxExperience)
+	}
+	if filter.MinSalary != nil {
+		query = query.Where("salary >= ?", *filter.MinSalary)
+	}
+	if filter.MaxSalary != nil {
+		query = query.Where("salary <= ?", *filter.MaxSalary)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page := filter.Pagination.Normalize()
+	var cats []models.Cat
+	err := applySort(query, filter.Sort).
+		Limit(page.Limit).
+		Offset(page.Offset()).
+		Find(&cats).Error
+	return cats, total, err
+}
+
 func (r *CatRepository) FindByID(ctx context.Context, id uint) (*models.Cat, error) {
 	var cat models.Cat
 	err := r.store.db.WithContext(ctx).First(&cat, id).Error
 	if err != nil {
-		return nil, err
+		return nil, translate(err, repo.ErrCatNotFound)
 	}
 	return &cat, nil
 }
@@ -36,27 +71,11 @@ func (r *CatRepository) UpdateSalary(ctx context.Context, id uint, salary float6
 		Where("id = ?", id).
 		Update("salary", salary)
 
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-
-	return nil
+	return affected(result, repo.ErrCatNotFound)
 }
 
 func (r *CatRepository) DeleteByID(ctx context.Context, id uint) error {
 	result := r.store.db.WithContext(ctx).Delete(&models.Cat{}, id)
 
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-
-	return nil
+	return affected(result, repo.ErrCatNotFound)
 }
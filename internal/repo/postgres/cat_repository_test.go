@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"DevelopsToday/internal/models"
+	dbrepo "DevelopsToday/internal/repo"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -15,7 +16,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
 
-	err = db.AutoMigrate(&models.Cat{}, &models.Mission{}, &models.Target{})
+	err = db.AutoMigrate(&models.Cat{}, &models.Mission{}, &models.Target{}, &models.User{}, &models.AuditLog{})
 	assert.NoError(t, err)
 
 	return db
@@ -61,6 +62,31 @@ func TestCatRepository(t *testing.T) {
 		assert.GreaterOrEqual(t, len(foundCats), 2)
 	})
 
+	t.Run("FindPage should filter, sort and paginate cats", func(t *testing.T) {
+		cats := []models.Cat{
+			{Name: "Page_A", Experience: 7, Breed: "PageBreed", Salary: 700},
+			{Name: "Page_B", Experience: 8, Breed: "PageBreed", Salary: 800},
+			{Name: "Page_C", Experience: 9, Breed: "PageBreed", Salary: 900},
+		}
+		for i := range cats {
+			err := repo.Create(ctx, &cats[i])
+			assert.NoError(t, err)
+		}
+
+		minSalary := 750.0
+		found, total, err := repo.FindPage(ctx, dbrepo.CatFilter{
+			Breed:      "pagebreed",
+			NamePrefix: "Page_",
+			MinSalary:  &minSalary,
+			Sort:       []dbrepo.SortField{{Field: "salary", Desc: true}},
+			Pagination: dbrepo.Pagination{Page: 1, Limit: 1},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, found, 1)
+		assert.Equal(t, "Page_C", found[0].Name)
+	})
+
 	t.Run("FindByID should return existing cat", func(t *testing.T) {
 		cat := &models.Cat{
 			Name:       "FindByCat",
@@ -80,7 +106,7 @@ func TestCatRepository(t *testing.T) {
 	t.Run("FindByID should return error for non-existing cat", func(t *testing.T) {
 		_, err := repo.FindByID(ctx, 999)
 		assert.Error(t, err)
-		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		assert.ErrorIs(t, err, dbrepo.ErrCatNotFound)
 	})
 
 	t.Run("UpdateSalary should update cat salary", func(t *testing.T) {
@@ -122,7 +148,7 @@ func TestCatRepository(t *testing.T) {
 		// Verify cat was deleted
 		_, err = repo.FindByID(ctx, cat.ID)
 		assert.Error(t, err)
-		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		assert.ErrorIs(t, err, dbrepo.ErrCatNotFound)
 	})
 
 	t.Run("DeleteByID should return error for non-existing cat", func(t *testing.T) {
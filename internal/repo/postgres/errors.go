@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// translate replaces gorm.ErrRecordNotFound with the given repository error
+func translate(err, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// affected turns an update or delete result into a repository error,
+// reporting notFound when no rows matched
+func affected(result *gorm.DB, notFound error) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MissionRepository struct {
@@ -14,27 +18,57 @@ func (r *MissionRepository) Create(ctx context.Context, mission *models.Mission)
 	return r.store.db.WithContext(ctx).Create(mission).Error
 }
 
+// AssignCat assigns a cat to a mission. The cat and mission rows are locked for
+// the duration of the transaction so two concurrent assignments cannot give
+// the same cat two active missions.
 func (r *MissionRepository) AssignCat(ctx context.Context, missionID, catID uint) error {
-	return r.store.db.WithContext(ctx).
-		Model(&models.Mission{}).
-		Where("id = ?", missionID).
-		Update("cat_id", catID).Error
+	return r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cat models.Cat
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cat, catID).Error; err != nil {
+			return translate(err, repo.ErrCatNotFound)
+		}
+
+		var mission models.Mission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&mission, missionID).Error; err != nil {
+			return translate(err, repo.ErrMissionNotFound)
+		}
+
+		if mission.Complete {
+			return repo.ErrMissionComplete
+		}
+
+		var active int64
+		err := tx.Model(&models.Mission{}).
+			Where("cat_id = ? AND complete = ? AND id <> ?", catID, false, missionID).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return repo.ErrCatBusy
+		}
+
+		return tx.Model(&models.Mission{}).
+			Where("id = ?", missionID).
+			Update("cat_id", catID).Error
+	})
 }
 
 func (r *MissionRepository) MarkComplete(ctx context.Context, id uint) error {
-	return r.store.db.WithContext(ctx).
+	result := r.store.db.WithContext(ctx).
 		Model(&models.Mission{}).
 		Where("id = ?", id).
-		Update("complete", true).Error
+		Update("complete", true)
+	return affected(result, repo.ErrMissionNotFound)
 }
 
 func (r *MissionRepository) FindByID(ctx context.Context, id uint) (*models.Mission, error) {
 	var m models.Mission
-	err := r.store.db.WithContext(ctx).
+	err := r.store.forUpdate(r.store.db.WithContext(ctx)).
 		Preload("Targets").
 		First(&m, id).Error
 	if err != nil {
-		return nil, err
+		return nil, translate(err, repo.ErrMissionNotFound)
 	}
 	return &m, nil
 }
@@ -47,6 +81,44 @@ func (r *MissionRepository) FindAll(ctx context.Context) ([]models.Mission, erro
 	return missions, err
 }
 
+func (r *MissionRepository) FindPage(ctx context.Context, filter repo.MissionFilter) ([]models.Mission, int64, error) {
+	query := r.store.db.WithContext(ctx).Model(&models.Mission{})
+
+	if filter.Complete != nil {
+		query = query.Where("complete = ?", *filter.Complete)
+	}
+	if filter.CatID != nil {
+		query = query.Where("cat_id = ?", *filter.CatID)
+	}
+	if filter.Unassigned {
+		query = query.Where("cat_id IS NULL")
+	}
+	if filter.TargetCountry != "" {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM targets WHERE targets.mission_id = missions.id AND LOWER(targets.country) = LOWER(?))",
+			filter.TargetCountry,
+		)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page := filter.Pagination.Normalize()
+	query = applySort(query, filter.Sort).
+		Limit(page.Limit).
+		Offset(page.Offset())
+	if !filter.SkipTargets {
+		query = query.Preload("Targets")
+	}
+
+	var missions []models.Mission
+	err := query.Find(&missions).Error
+	return missions, total, err
+}
+
 func (r *MissionRepository) DeleteByID(ctx context.Context, id uint) error {
-	return r.store.db.WithContext(ctx).Delete(&models.Mission{}, id).Error
+	result := r.store.db.WithContext(ctx).Delete(&models.Mission{}, id)
+	return affected(result, repo.ErrMissionNotFound)
 }
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"DevelopsToday/internal/models"
+	dbrepo "DevelopsToday/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissionRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &MissionRepository{store: &Repository{db: db}}
+	ctx := context.Background()
+
+	cat := &models.Cat{Name: "MissionCat", Experience: 1, Breed: "Bengal", Salary: 100}
+	assert.NoError(t, db.Create(cat).Error)
+
+	missions := []models.Mission{
+		{CatID: &cat.ID, Targets: []models.Target{{Name: "T1", Country: "France"}}},
+		{Targets: []models.Target{{Name: "T2", Country: "Japan"}, {Name: "T3", Country: "France"}}},
+		{Complete: true, Targets: []models.Target{{Name: "T4", Country: "Japan", Complete: true}}},
+	}
+	for i := range missions {
+		assert.NoError(t, repo.Create(ctx, &missions[i]))
+	}
+
+	t.Run("FindPage should filter by target country and status", func(t *testing.T) {
+		complete := false
+		found, total, err := repo.FindPage(ctx, dbrepo.MissionFilter{
+			Complete:      &complete,
+			TargetCountry: "france",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, found, 2)
+	})
+
+	t.Run("FindPage should filter unassigned missions without targets", func(t *testing.T) {
+		found, total, err := repo.FindPage(ctx, dbrepo.MissionFilter{
+			Unassigned:  true,
+			SkipTargets: true,
+			Sort:        []dbrepo.SortField{{Field: "id", Desc: true}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, missions[2].ID, found[0].ID)
+		assert.Empty(t, found[0].Targets)
+	})
+
+	t.Run("FindPage should filter by cat and preload targets", func(t *testing.T) {
+		found, total, err := repo.FindPage(ctx, dbrepo.MissionFilter{CatID: &cat.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, found[0].Targets, 1)
+	})
+
+	t.Run("AssignCat should reject a cat busy with another mission", func(t *testing.T) {
+		err := repo.AssignCat(ctx, missions[1].ID, cat.ID)
+		assert.ErrorIs(t, err, dbrepo.ErrCatBusy)
+	})
+
+	t.Run("AssignCat should reject a completed mission", func(t *testing.T) {
+		err := repo.AssignCat(ctx, missions[2].ID, cat.ID)
+		assert.ErrorIs(t, err, dbrepo.ErrMissionComplete)
+	})
+
+	t.Run("AssignCat should report missing cat and mission", func(t *testing.T) {
+		assert.ErrorIs(t, repo.AssignCat(ctx, missions[1].ID, 999), dbrepo.ErrCatNotFound)
+		assert.ErrorIs(t, repo.AssignCat(ctx, 999, cat.ID), dbrepo.ErrMissionNotFound)
+	})
+
+	t.Run("AssignCat should assign a free cat", func(t *testing.T) {
+		free := &models.Cat{Name: "FreeCat", Experience: 1, Breed: "Bengal", Salary: 100}
+		assert.NoError(t, db.Create(free).Error)
+
+		err := repo.AssignCat(ctx, missions[1].ID, free.ID)
+		assert.NoError(t, err)
+
+		found, err := repo.FindByID(ctx, missions[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, free.ID, *found.CatID)
+	})
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"strings"
+
+	"DevelopsToday/internal/repo"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// applySort orders the query by the given fields, falling back to id so that
+// paging stays stable. Field names are expected to be validated by repo.ParseSort.
+func applySort(query *gorm.DB, fields []repo.SortField) *gorm.DB {
+	hasID := false
+	for _, f := range fields {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: f.Field}, Desc: f.Desc})
+		if f.Field == "id" {
+			hasID = true
+		}
+	}
+	if !hasID {
+		query = query.Order("id")
+	}
+	return query
+}
+
+// escapeLike escapes LIKE wildcards in user supplied input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -1,23 +1,45 @@
 package postgres
 
 import (
+	"context"
+
 	"DevelopsToday/internal/repo"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
-	db                *gorm.DB
-	catRepository     *CatRepository
-	missionRepository *MissionRepository
-	targetRepository  *TargetRepository
-	userRepository    *UserRepository
+	db                 *gorm.DB
+	catRepository      *CatRepository
+	missionRepository  *MissionRepository
+	targetRepository   *TargetRepository
+	userRepository     *UserRepository
+	auditLogRepository *AuditLogRepository
+	// inTx is set for repositories bound to a WithTx transaction
+	inTx bool
 }
 
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// WithTx runs fn inside a database transaction. Nested calls use savepoints.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx repo.Store) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Repository{db: tx, inTx: true})
+	})
+}
+
+// forUpdate locks the selected rows when running inside WithTx, so that a
+// read followed by a write in the same transaction cannot race
+func (r *Repository) forUpdate(db *gorm.DB) *gorm.DB {
+	if !r.inTx {
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
 func (r *Repository) Cat() repo.CatRepository {
 	if r.catRepository != nil {
 		return r.catRepository
@@ -42,7 +64,7 @@ func (r *Repository) Mission() repo.MissionRepository {
 	return r.missionRepository
 }
 func (r *Repository) Target() repo.TargetRepository {
-	if r.missionRepository != nil {
+	if r.targetRepository != nil {
 		return r.targetRepository
 	}
 
@@ -64,3 +86,15 @@ func (r *Repository) User() repo.UserRepository {
 
 	return r.userRepository
 }
+
+func (r *Repository) AuditLog() repo.AuditLogRepository {
+	if r.auditLogRepository != nil {
+		return r.auditLogRepository
+	}
+
+	r.auditLogRepository = &AuditLogRepository{
+		store: r,
+	}
+
+	return r.auditLogRepository
+}
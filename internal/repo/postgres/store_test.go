@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryWithTx(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewRepository(db)
+	ctx := context.Background()
+
+	cat := &models.Cat{Name: "TxCat", Experience: 1, Breed: "Bengal", Salary: 100}
+	assert.NoError(t, store.Cat().Create(ctx, cat))
+
+	t.Run("WithTx should commit changes when fn succeeds", func(t *testing.T) {
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			return tx.Cat().UpdateSalary(ctx, cat.ID, 200)
+		})
+		assert.NoError(t, err)
+
+		found, err := store.Cat().FindByID(ctx, cat.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(200), found.Salary)
+	})
+
+	t.Run("WithTx should roll back changes when fn fails", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := store.WithTx(ctx, func(tx repo.Store) error {
+			if err := tx.Cat().UpdateSalary(ctx, cat.ID, 999); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		assert.ErrorIs(t, err, errBoom)
+
+		found, err := store.Cat().FindByID(ctx, cat.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(200), found.Salary)
+	})
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TargetRepository struct {
@@ -11,24 +15,72 @@ type TargetRepository struct {
 }
 
 func (r *TargetRepository) AddToMission(ctx context.Context, missionID uint, target *models.Target) error {
-	target.ID = missionID
+	target.MissionID = missionID
 	return r.store.db.WithContext(ctx).Create(target).Error
 }
 
 func (r *TargetRepository) UpdateNotes(ctx context.Context, targetID uint, notes string) error {
-	return r.store.db.WithContext(ctx).
+	result := r.store.db.WithContext(ctx).
 		Model(&models.Target{}).
 		Where("id = ?", targetID).
-		Update("notes", notes).Error
+		Update("notes", notes)
+	return affected(result, repo.ErrTargetNotFound)
 }
 
-func (r *TargetRepository) MarkComplete(ctx context.Context, targetID uint) error {
-	return r.store.db.WithContext(ctx).
-		Model(&models.Target{}).
-		Where("id = ?", targetID).
-		Update("complete", true).Error
+// MarkComplete completes a target. When the parent mission has AutoComplete set
+// and this was its last open target, the mission is completed in the same transaction.
+func (r *TargetRepository) MarkComplete(ctx context.Context, targetID uint) (*repo.TargetCompletion, error) {
+	var result repo.TargetCompletion
+
+	err := r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target models.Target
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return translate(err, repo.ErrTargetNotFound)
+		}
+
+		var mission models.Mission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&mission, target.MissionID).Error; err != nil {
+			return translate(err, repo.ErrMissionNotFound)
+		}
+		result.MissionID = mission.ID
+		result.CatID = mission.CatID
+
+		err := tx.Model(&models.Target{}).
+			Where("id = ?", targetID).
+			Update("complete", true).Error
+		if err != nil {
+			return err
+		}
+
+		if !mission.AutoComplete || mission.Complete {
+			return nil
+		}
+
+		var open int64
+		err = tx.Model(&models.Target{}).
+			Where("mission_id = ? AND complete = ?", mission.ID, false).
+			Count(&open).Error
+		if err != nil || open > 0 {
+			return err
+		}
+
+		err = tx.Model(&models.Mission{}).
+			Where("id = ?", mission.ID).
+			Update("complete", true).Error
+		if err != nil {
+			return err
+		}
+		result.MissionCompleted = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
 }
 
 func (r *TargetRepository) DeleteByID(ctx context.Context, targetID uint) error {
-	return r.store.db.WithContext(ctx).Delete(&models.Target{}, targetID).Error
+	result := r.store.db.WithContext(ctx).Delete(&models.Target{}, targetID)
+	return affected(result, repo.ErrTargetNotFound)
 }
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"DevelopsToday/internal/models"
+	dbrepo "DevelopsToday/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &TargetRepository{store: &Repository{db: db}}
+	ctx := context.Background()
+
+	t.Run("MarkComplete should complete an auto_complete mission with its last target", func(t *testing.T) {
+		mission := &models.Mission{
+			AutoComplete: true,
+			Targets:      []models.Target{{Name: "A1", Country: "Spain"}, {Name: "A2", Country: "Spain"}},
+		}
+		assert.NoError(t, db.Create(mission).Error)
+
+		result, err := repo.MarkComplete(ctx, mission.Targets[0].ID)
+		assert.NoError(t, err)
+		assert.False(t, result.MissionCompleted)
+
+		result, err = repo.MarkComplete(ctx, mission.Targets[1].ID)
+		assert.NoError(t, err)
+		assert.True(t, result.MissionCompleted)
+		assert.Equal(t, mission.ID, result.MissionID)
+
+		var found models.Mission
+		assert.NoError(t, db.First(&found, mission.ID).Error)
+		assert.True(t, found.Complete)
+	})
+
+	t.Run("MarkComplete should leave other missions open", func(t *testing.T) {
+		mission := &models.Mission{Targets: []models.Target{{Name: "M1", Country: "Spain"}}}
+		assert.NoError(t, db.Create(mission).Error)
+
+		result, err := repo.MarkComplete(ctx, mission.Targets[0].ID)
+		assert.NoError(t, err)
+		assert.False(t, result.MissionCompleted)
+
+		var found models.Mission
+		assert.NoError(t, db.First(&found, mission.ID).Error)
+		assert.False(t, found.Complete)
+	})
+
+	t.Run("MarkComplete should return error for non-existing target", func(t *testing.T) {
+		_, err := repo.MarkComplete(ctx, 999)
+		assert.ErrorIs(t, err, dbrepo.ErrTargetNotFound)
+		assert.ErrorIs(t, err, dbrepo.ErrNotFound)
+	})
+
+	t.Run("UpdateNotes and DeleteByID should return error for non-existing target", func(t *testing.T) {
+		assert.ErrorIs(t, repo.UpdateNotes(ctx, 999, "notes"), dbrepo.ErrTargetNotFound)
+		assert.ErrorIs(t, repo.DeleteByID(ctx, 999), dbrepo.ErrTargetNotFound)
+	})
+}
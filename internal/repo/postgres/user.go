@@ -21,27 +21,34 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.store.db.WithContext(ctx).First(&user, id).Error
+	err := r.store.forUpdate(r.store.db.WithContext(ctx)).First(&user, id).Error
 	if err != nil {
-		return nil, err
+		return nil, translate(err, repo.ErrUserNotFound)
 	}
 	return &user, nil
 }
 
+// Lookups compare lower(column), which is what the unique indexes cover, so
+// they also find rows stored before names were normalized
+
 func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
-	var user models.User
-	err := r.store.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return r.findOne(ctx, "lower(username) = ?", models.NormalizeLogin(username))
 }
 
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, "lower(email) = ?", models.NormalizeLogin(email))
+}
+
+func (r *UserRepository) FindByLogin(ctx context.Context, login string) (*models.User, error) {
+	login = models.NormalizeLogin(login)
+	return r.findOne(ctx, "lower(username) = ? OR lower(email) = ?", login, login)
+}
+
+func (r *UserRepository) findOne(ctx context.Context, query string, args ...interface{}) (*models.User, error) {
 	var user models.User
-	err := r.store.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := r.store.db.WithContext(ctx).Where(query, args...).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translate(err, repo.ErrUserNotFound)
 	}
 	return &user, nil
 }
@@ -50,8 +57,28 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return r.store.db.WithContext(ctx).Save(user).Error
 }
 
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, current, hash string) error {
+	result := r.store.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND password = ?", id, current).
+		Update("password", hash)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := r.store.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return repo.ErrUserNotFound
+	}
+	return repo.ErrPasswordChanged
+}
+
 func (r *UserRepository) DeleteByID(ctx context.Context, id uint) error {
-	return r.store.db.WithContext(ctx).Delete(&models.User{}, id).Error
+	result := r.store.db.WithContext(ctx).Delete(&models.User{}, id)
+	return affected(result, repo.ErrUserNotFound)
 }
 
 func (r *UserRepository) FindAll(ctx context.Context, limit, offset int) ([]*models.User, error) {
@@ -60,3 +87,20 @@ func (r *UserRepository) FindAll(ctx context.Context, limit, offset int) ([]*mod
 	err := query.Find(&users).Error
 	return users, err
 }
+
+func (r *UserRepository) FindPage(ctx context.Context, page repo.Pagination) ([]models.User, int64, error) {
+	query := r.store.db.WithContext(ctx).Model(&models.User{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page = page.Normalize()
+	var users []models.User
+	err := query.Order("id").
+		Limit(page.Limit).
+		Offset(page.Offset()).
+		Find(&users).Error
+	return users, total, err
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"DevelopsToday/internal/models"
+	dbrepo "DevelopsToday/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &UserRepository{store: &Repository{db: db}}
+	ctx := context.Background()
+
+	user := &models.User{Username: " Whiskers ", Email: "Whiskers@SpyCats.com", Password: "secret"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	t.Run("Create should store the username and email normalized", func(t *testing.T) {
+		var stored models.User
+		require.NoError(t, db.First(&stored, user.ID).Error)
+		assert.Equal(t, "whiskers", stored.Username)
+		assert.Equal(t, "whiskers@spycats.com", stored.Email)
+	})
+
+	t.Run("usernames and emails should be unique regardless of case", func(t *testing.T) {
+		err := db.Create(&models.User{Username: "other", Email: "other@spycats.com", Password: "secret"}).Error
+		require.NoError(t, err)
+
+		// Bypass the hook, the indexes alone must reject these
+		insert := "INSERT INTO users (username, email, password) VALUES (?, ?, ?)"
+		require.NoError(t, db.Exec(insert, "Mittens", "Mittens@spycats.com", "x").Error)
+
+		err = db.Exec(insert, "WHISKERS", "new@spycats.com", "x").Error
+		assert.Error(t, err)
+		err = db.Exec(insert, "new", "OTHER@spycats.com", "x").Error
+		assert.Error(t, err)
+	})
+
+	t.Run("lookups should ignore case and whitespace", func(t *testing.T) {
+		found, err := repo.FindByUsername(ctx, "WHISKERS ")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+
+		found, err = repo.FindByEmail(ctx, "whiskers@spycats.COM")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+	})
+
+	t.Run("FindByLogin should accept the username or the email", func(t *testing.T) {
+		for _, login := range []string{"Whiskers", "WHISKERS@spycats.com"} {
+			found, err := repo.FindByLogin(ctx, login)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, found.ID)
+		}
+
+		_, err := repo.FindByLogin(ctx, "felix")
+		assert.ErrorIs(t, err, dbrepo.ErrUserNotFound)
+	})
+
+	t.Run("Create should keep a password that is already hashed", func(t *testing.T) {
+		imported := &models.User{Username: "imported", Email: "imported@spycats.com", Password: "secret"}
+		require.NoError(t, imported.HashPasswordWithCost(bcrypt.MinCost))
+		hash := imported.Password
+
+		require.NoError(t, repo.Create(ctx, imported))
+
+		var stored models.User
+		require.NoError(t, db.First(&stored, imported.ID).Error)
+		assert.Equal(t, hash, stored.Password)
+		assert.True(t, stored.CheckPassword("secret"))
+	})
+
+	t.Run("UpdatePassword should only replace the current password", func(t *testing.T) {
+		stored, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("role", "admin").Error)
+
+		err = repo.UpdatePassword(ctx, user.ID, "not-the-hash", "new-hash")
+		assert.ErrorIs(t, err, dbrepo.ErrPasswordChanged)
+		err = repo.UpdatePassword(ctx, 999, stored.Password, "new-hash")
+		assert.ErrorIs(t, err, dbrepo.ErrUserNotFound)
+
+		require.NoError(t, repo.UpdatePassword(ctx, user.ID, stored.Password, "new-hash"))
+		updated, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", updated.Password)
+		// Columns changed since the user was loaded are kept
+		assert.Equal(t, "admin", updated.Role)
+	})
+}
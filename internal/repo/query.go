@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+// Pagination holds page based paging parameters
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Normalize fills in defaults and clamps the limit
+func (p Pagination) Normalize() Pagination {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	return p
+}
+
+// Offset returns the number of rows to skip for the current page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// SortField describes a single ordering column
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// CatSortFields lists the fields cats can be sorted by
+var CatSortFields = []string{"id", "name", "breed", "experience", "salary"}
+
+// CatFilter narrows down and orders a cat listing
+type CatFilter struct {
+	MinExperience *int
+	MaxExperience *int
+	MinSalary     *float64
+	MaxSalary     *float64
+	Breed         string
+	NamePrefix    string
+	Sort          []SortField
+	Pagination
+}
+
+// MissionSortFields lists the fields missions can be sorted by
+var MissionSortFields = []string{"id", "cat_id", "complete"}
+
+// AuditLogFilter narrows down an audit trail listing
+type AuditLogFilter struct {
+	// SubjectID limits the listing to actions on one user
+	SubjectID *uint
+	Pagination
+}
+
+// MissionFilter narrows down and orders a mission listing
+type MissionFilter struct {
+	Complete      *bool
+	CatID         *uint
+	TargetCountry string
+	Sort          []SortField
+	Pagination
+	// Unassigned limits the listing to missions without a cat
+	Unassigned bool
+	// SkipTargets leaves the Targets association unloaded
+	SkipTargets bool
+}
+
+// ParseSort parses a comma separated sort expression such as "name,-salary".
+// A leading "-" means descending order. Only fields from allowed are accepted.
+func ParseSort(raw string, allowed []string) ([]SortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		}
+
+		if !slices.Contains(allowed, field.Field) {
+			return nil, fmt.Errorf("unsupported sort field: %s", field.Field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
@@ -18,7 +18,7 @@ func Seed(db *gorm.DB) error {
 	if userCount == 0 {
 		users := []models.User{
 			{Username: defaultAdminUsername, Email: "admin@spycats.com", Password: defaultAdminPassword, Role: "admin"},
-			{Username: "agent", Email: "agent@spycats.com", Password: "agent123", Role: "user"},
+			{Username: "agent", Email: "agent@spycats.com", Password: "agent123", Role: "agent"},
 			{Username: "manager", Email: "manager@spycats.com", Password: "manager123", Role: "manager"},
 		}
 		for _, user := range users {
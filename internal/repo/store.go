@@ -12,12 +12,17 @@ type Store interface {
 	Mission() MissionRepository
 	Target() TargetRepository
 	User() UserRepository
+	AuditLog() AuditLogRepository
+	// WithTx runs fn inside a transaction. The Store passed to fn is bound to
+	// the transaction; it is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
 	// ... other entity
 }
 
 type CatRepository interface {
 	Create(ctx context.Context, cat *models.Cat) error
 	FindAll(ctx context.Context) ([]models.Cat, error)
+	FindPage(ctx context.Context, filter CatFilter) ([]models.Cat, int64, error)
 	FindByID(ctx context.Context, id uint) (*models.Cat, error)
 	UpdateSalary(ctx context.Context, id uint, salary float64) error
 	DeleteByID(ctx context.Context, id uint) error
@@ -25,6 +30,7 @@ type CatRepository interface {
 type MissionRepository interface {
 	Create(ctx context.Context, mission *models.Mission) error
 	FindAll(ctx context.Context) ([]models.Mission, error)
+	FindPage(ctx context.Context, filter MissionFilter) ([]models.Mission, int64, error)
 	FindByID(ctx context.Context, id uint) (*models.Mission, error)
 	AssignCat(ctx context.Context, id uint, catID uint) error
 	MarkComplete(ctx context.Context, id uint) error
@@ -33,16 +39,39 @@ type MissionRepository interface {
 type TargetRepository interface {
 	AddToMission(ctx context.Context, missionID uint, target *models.Target) error
 	UpdateNotes(ctx context.Context, targetID uint, notes string) error
-	MarkComplete(ctx context.Context, targetID uint) error
+	MarkComplete(ctx context.Context, targetID uint) (*TargetCompletion, error)
 	DeleteByID(ctx context.Context, id uint) error
 }
 
+// TargetCompletion describes the outcome of completing a target
+type TargetCompletion struct {
+	CatID     *uint
+	MissionID uint
+	// MissionCompleted reports whether the parent mission was completed
+	// automatically in the same transaction
+	MissionCompleted bool
+}
+
+// UserRepository lookups by username or email ignore case and surrounding
+// whitespace
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByID(ctx context.Context, id uint) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// FindByLogin finds a user by username or by email
+	FindByLogin(ctx context.Context, login string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	// UpdatePassword replaces only the password hash of a user, and only while
+	// it is still current. It returns ErrPasswordChanged otherwise.
+	UpdatePassword(ctx context.Context, id uint, current, hash string) error
 	DeleteByID(ctx context.Context, id uint) error
 	FindAll(ctx context.Context, limit, offset int) ([]*models.User, error)
+	FindPage(ctx context.Context, page Pagination) ([]models.User, int64, error)
+}
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// FindPage returns the newest entries first
+	FindPage(ctx context.Context, filter AuditLogFilter) ([]models.AuditLog, int64, error)
 }
@@ -2,15 +2,24 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ErrCacheMiss is returned by Get and GetJSON when the key does not exist or has expired
+var ErrCacheMiss = errors.New("cache: key not found")
+
 // CacheService defines the interface for caching operations
 type CacheService interface {
-	// Set stores a key-value pair with optional TTL
+	// Set stores a key-value pair with optional TTL.
+	// A ttl <= 0 stores the value without expiration.
 	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
 
-	// Get retrieves a value by key
+	// Get retrieves a value by key. It returns ErrCacheMiss for missing keys.
 	Get(ctx context.Context, key string) (string, error)
 
 	// Delete removes a key from cache
@@ -19,10 +28,10 @@ type CacheService interface {
 	// Exists checks if a key exists in cache
 	Exists(ctx context.Context, key string) (bool, error)
 
-	// SetJSON stores a JSON-serializable object
+	// SetJSON stores a JSON-serializable object, with the same TTL rules as Set
 	SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error
 
-	// GetJSON retrieves and unmarshals a JSON object
+	// GetJSON retrieves and unmarshals a JSON object. It returns ErrCacheMiss for missing keys.
 	GetJSON(ctx context.Context, key string, dest interface{}) error
 
 	// Ping checks if the cache service is available
@@ -31,3 +40,79 @@ type CacheService interface {
 	// Close closes the cache connection
 	Close() error
 }
+
+// CacheCounter is implemented by caches that can count atomically across
+// instances
+type CacheCounter interface {
+	// Increment adds one to the counter at key and returns the new value. The
+	// counter expires ttl after the last increment.
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// Counter returns the value of the counter at key, 0 when it does not exist
+	Counter(ctx context.Context, key string) (int64, error)
+}
+
+// CacheAtomic is implemented by caches that can update a value atomically
+// across instances
+type CacheAtomic interface {
+	// CompareAndSwap stores value at key only when the key currently holds
+	// old, and reports whether it did. A missing key never matches. The TTL
+	// follows the rules of Set.
+	CompareAndSwap(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error)
+
+	// SetIfAbsent stores value only when key does not exist, and reports
+	// whether it did. The TTL follows the rules of Set.
+	SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+
+	// GetDelete returns the value at key and removes it in one step, so that
+	// only one caller gets it. It returns ErrCacheMiss for missing keys.
+	GetDelete(ctx context.Context, key string) (string, error)
+}
+
+// securityKeyPrefixes are the key prefixes of token revocations, sessions,
+// one-time codes and login counters
+var securityKeyPrefixes = []string{
+	"blacklist:",
+	"revoked_before:",
+	"session:",
+	"sessions:",
+	"invitation:",
+	"password_reset:",
+	"login_attempts:",
+	"ratelimit:",
+}
+
+// IsSecurityKey reports whether key holds security state. A bounded cache must
+// never evict such keys, since losing one would revive a revoked token or
+// reset a lockout.
+func IsSecurityKey(key string) bool {
+	for _, prefix := range securityKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeJSON loads the JSON object at key into dest and removes it in one step,
+// so that of concurrent callers only one gets it. Caches without CacheAtomic
+// serialize callers on mutex, which only holds within this instance.
+func takeJSON(ctx context.Context, cache CacheService, mutex *sync.Mutex, key string, dest interface{}) error {
+	if atomic, ok := cache.(CacheAtomic); ok {
+		value, err := atomic.GetDelete(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(value), dest); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+		return nil
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err := cache.GetJSON(ctx, key, dest); err != nil {
+		return err
+	}
+	return cache.Delete(ctx, key)
+}
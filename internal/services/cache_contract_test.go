@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheBackend is a CacheService under test together with a way to move its
+// clock forward, so TTL expiry can be checked without sleeping
+type cacheBackend struct {
+	cache       CacheService
+	fastForward func(time.Duration)
+}
+
+// cacheBackends lists every CacheService implementation. A new implementation
+// only has to be added here to run the whole contract suite.
+var cacheBackends = map[string]func(t *testing.T) cacheBackend{
+	"redis": func(t *testing.T) cacheBackend {
+		mr := miniredis.RunT(t)
+		cache := NewRedisCacheService(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		t.Cleanup(func() { cache.Close() })
+		return cacheBackend{cache: cache, fastForward: mr.FastForward}
+	},
+	"memory": func(t *testing.T) cacheBackend {
+		clock := newTestClock()
+		cache := NewMemoryCacheService().(*MemoryCacheService)
+		cache.now = clock.Now
+		t.Cleanup(func() { cache.Close() })
+		return cacheBackend{cache: cache, fastForward: clock.Advance}
+	},
+	"tiered": func(t *testing.T) cacheBackend {
+		mr := miniredis.RunT(t)
+		clock := newTestClock()
+		cache := newTestTieredCache(t, mr, clock)
+		return cacheBackend{cache: cache, fastForward: func(d time.Duration) {
+			clock.Advance(d)
+			mr.FastForward(d)
+		}}
+	},
+	"memcached": func(t *testing.T) cacheBackend {
+		server := runMemcachedStandIn(t)
+		cache := NewMemcachedCacheService(memcache.New(server.Addr()))
+		t.Cleanup(func() { cache.Close() })
+		return cacheBackend{cache: cache, fastForward: server.FastForward}
+	},
+}
+
+func TestCacheContract(t *testing.T) {
+	for name, newBackend := range cacheBackends {
+		t.Run(name, func(t *testing.T) {
+			runCacheContract(t, newBackend)
+		})
+	}
+}
+
+// runCacheContract checks the behaviour every CacheService implementation
+// must provide. Each subtest gets a fresh backend.
+func runCacheContract(t *testing.T, newBackend func(t *testing.T) cacheBackend) {
+	ctx := context.Background()
+
+	t.Run("Set and Get should round trip a string", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:string", "value", time.Minute))
+
+		result, err := cache.Get(ctx, "contract:string")
+		require.NoError(t, err)
+		assert.Equal(t, "value", result)
+	})
+
+	t.Run("Set should overwrite an existing value", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:overwrite", "first", time.Minute))
+		require.NoError(t, cache.Set(ctx, "contract:overwrite", "second", time.Minute))
+
+		result, err := cache.Get(ctx, "contract:overwrite")
+		require.NoError(t, err)
+		assert.Equal(t, "second", result)
+	})
+
+	t.Run("Set should store integers in decimal form", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:int", 42, time.Minute))
+
+		result, err := cache.Get(ctx, "contract:int")
+		require.NoError(t, err)
+		assert.Equal(t, "42", result)
+	})
+
+	t.Run("Get on a missing key should return ErrCacheMiss", func(t *testing.T) {
+		cache := newBackend(t).cache
+		result, err := cache.Get(ctx, "contract:missing")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		assert.Empty(t, result)
+	})
+
+	t.Run("keys should expire after their TTL", func(t *testing.T) {
+		backend := newBackend(t)
+		cache := backend.cache
+		require.NoError(t, cache.Set(ctx, "contract:ttl", "value", 5*time.Second))
+
+		backend.fastForward(2 * time.Second)
+		result, err := cache.Get(ctx, "contract:ttl")
+		require.NoError(t, err, "key must live until its TTL passes")
+		assert.Equal(t, "value", result)
+
+		backend.fastForward(5 * time.Second)
+		_, err = cache.Get(ctx, "contract:ttl")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+
+		exists, err := cache.Exists(ctx, "contract:ttl")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Set with ttl <= 0 should never expire", func(t *testing.T) {
+		backend := newBackend(t)
+		cache := backend.cache
+		require.NoError(t, cache.Set(ctx, "contract:ttl:zero", "zero", 0))
+		require.NoError(t, cache.Set(ctx, "contract:ttl:negative", "negative", -time.Second))
+
+		// Longer than the year MemoryCacheService used to substitute for "forever"
+		backend.fastForward(2 * 365 * 24 * time.Hour)
+
+		result, err := cache.Get(ctx, "contract:ttl:zero")
+		require.NoError(t, err)
+		assert.Equal(t, "zero", result)
+
+		result, err = cache.Get(ctx, "contract:ttl:negative")
+		require.NoError(t, err)
+		assert.Equal(t, "negative", result)
+	})
+
+	t.Run("Set with ttl <= 0 should drop a previous TTL", func(t *testing.T) {
+		backend := newBackend(t)
+		cache := backend.cache
+		require.NoError(t, cache.Set(ctx, "contract:ttl:reset", "first", time.Second))
+		require.NoError(t, cache.Set(ctx, "contract:ttl:reset", "second", -time.Second))
+
+		backend.fastForward(time.Minute)
+
+		result, err := cache.Get(ctx, "contract:ttl:reset")
+		require.NoError(t, err)
+		assert.Equal(t, "second", result)
+	})
+
+	t.Run("Exists should report stored keys only", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:exists", "value", time.Minute))
+
+		exists, err := cache.Exists(ctx, "contract:exists")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = cache.Exists(ctx, "contract:exists:missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Exists should be false after Delete", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:delete", "value", time.Minute))
+		require.NoError(t, cache.Delete(ctx, "contract:delete"))
+
+		exists, err := cache.Exists(ctx, "contract:delete")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		_, err = cache.Get(ctx, "contract:delete")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("Delete on a missing key should succeed", func(t *testing.T) {
+		cache := newBackend(t).cache
+		assert.NoError(t, cache.Delete(ctx, "contract:delete:missing"))
+	})
+
+	t.Run("SetJSON and GetJSON should round trip a struct", func(t *testing.T) {
+		type payload struct {
+			ID        uint              `json:"id"`
+			Name      string            `json:"name"`
+			Salary    float64           `json:"salary"`
+			Tags      []string          `json:"tags"`
+			Meta      map[string]string `json:"meta"`
+			CreatedAt time.Time         `json:"created_at"`
+		}
+		in := payload{
+			ID:        7,
+			Name:      "Whiskers",
+			Salary:    1500.5,
+			Tags:      []string{"stealth", "night"},
+			Meta:      map[string]string{"breed": "Siamese"},
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		}
+
+		cache := newBackend(t).cache
+		require.NoError(t, cache.SetJSON(ctx, "contract:json", in, time.Minute))
+
+		var out payload
+		require.NoError(t, cache.GetJSON(ctx, "contract:json", &out))
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("GetJSON on a missing key should return ErrCacheMiss", func(t *testing.T) {
+		cache := newBackend(t).cache
+		var out map[string]any
+		assert.ErrorIs(t, cache.GetJSON(ctx, "contract:json:missing", &out), ErrCacheMiss)
+	})
+
+	t.Run("GetJSON on a non JSON value should fail", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:json:invalid", "not json {", time.Minute))
+
+		var out map[string]any
+		err := cache.GetJSON(ctx, "contract:json:invalid", &out)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("SetJSON with an unmarshalable value should fail", func(t *testing.T) {
+		cache := newBackend(t).cache
+		assert.Error(t, cache.SetJSON(ctx, "contract:json:chan", make(chan int), time.Minute))
+	})
+
+	t.Run("concurrent access should be safe", func(t *testing.T) {
+		cache := newBackend(t).cache
+
+		const workers = 8
+		const iterations = 50
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					key := fmt.Sprintf("contract:concurrent:%d", i%5)
+					value := fmt.Sprintf("%d-%d", w, i)
+
+					if err := cache.Set(ctx, key, value, time.Minute); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := cache.Get(ctx, key); err != nil && !errors.Is(err, ErrCacheMiss) {
+						errs <- err
+						return
+					}
+					if _, err := cache.Exists(ctx, key); err != nil {
+						errs <- err
+						return
+					}
+					if i%7 == 0 {
+						if err := cache.Delete(ctx, key); err != nil {
+							errs <- err
+							return
+						}
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+
+	t.Run("CompareAndSwap should replace the expected value only", func(t *testing.T) {
+		cache := newBackend(t).cache
+		atomic, ok := cache.(CacheAtomic)
+		require.True(t, ok, "every cache must implement CacheAtomic")
+
+		swapped, err := atomic.CompareAndSwap(ctx, "contract:cas", "", "value", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, swapped, "a missing key must not match")
+
+		require.NoError(t, cache.Set(ctx, "contract:cas", "first", time.Minute))
+
+		swapped, err = atomic.CompareAndSwap(ctx, "contract:cas", "other", "second", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, swapped)
+
+		swapped, err = atomic.CompareAndSwap(ctx, "contract:cas", "first", "second", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, swapped)
+
+		result, err := cache.Get(ctx, "contract:cas")
+		require.NoError(t, err)
+		assert.Equal(t, "second", result)
+
+		swapped, err = atomic.CompareAndSwap(ctx, "contract:cas", "first", "third", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, swapped, "the old value must not match twice")
+	})
+
+	t.Run("CompareAndSwap should apply the new TTL", func(t *testing.T) {
+		backend := newBackend(t)
+		cache := backend.cache
+		require.NoError(t, cache.Set(ctx, "contract:cas:ttl", "first", 0))
+
+		swapped, err := cache.(CacheAtomic).CompareAndSwap(ctx, "contract:cas:ttl", "first", "second", 5*time.Second)
+		require.NoError(t, err)
+		require.True(t, swapped)
+
+		backend.fastForward(6 * time.Second)
+		_, err = cache.Get(ctx, "contract:cas:ttl")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("concurrent CompareAndSwap should let exactly one writer win", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:cas:race", "initial", time.Minute))
+
+		const workers = 8
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		winners := 0
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				swapped, err := cache.(CacheAtomic).CompareAndSwap(ctx, "contract:cas:race", "initial", fmt.Sprint(w), time.Minute)
+				assert.NoError(t, err)
+				if swapped {
+					mutex.Lock()
+					winners++
+					mutex.Unlock()
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, winners)
+	})
+
+	t.Run("SetIfAbsent should store only into a missing or expired key", func(t *testing.T) {
+		backend := newBackend(t)
+		cache := backend.cache
+
+		stored, err := cache.(CacheAtomic).SetIfAbsent(ctx, "contract:nx", "first", 5*time.Second)
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		stored, err = cache.(CacheAtomic).SetIfAbsent(ctx, "contract:nx", "second", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		result, err := cache.Get(ctx, "contract:nx")
+		require.NoError(t, err)
+		assert.Equal(t, "first", result)
+
+		backend.fastForward(6 * time.Second)
+		stored, err = cache.(CacheAtomic).SetIfAbsent(ctx, "contract:nx", "third", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		result, err = cache.Get(ctx, "contract:nx")
+		require.NoError(t, err)
+		assert.Equal(t, "third", result)
+	})
+
+	t.Run("GetDelete should return the value once", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:getdel", "value", time.Minute))
+
+		result, err := cache.(CacheAtomic).GetDelete(ctx, "contract:getdel")
+		require.NoError(t, err)
+		assert.Equal(t, "value", result)
+
+		_, err = cache.(CacheAtomic).GetDelete(ctx, "contract:getdel")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		exists, err := cache.Exists(ctx, "contract:getdel")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("concurrent GetDelete should hand the value to exactly one caller", func(t *testing.T) {
+		cache := newBackend(t).cache
+		require.NoError(t, cache.Set(ctx, "contract:getdel:race", "value", time.Minute))
+
+		const workers = 8
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		winners := 0
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cache.(CacheAtomic).GetDelete(ctx, "contract:getdel:race")
+				if errors.Is(err, ErrCacheMiss) {
+					return
+				}
+				assert.NoError(t, err)
+				mutex.Lock()
+				winners++
+				mutex.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, winners)
+	})
+
+	t.Run("Ping should succeed", func(t *testing.T) {
+		cache := newBackend(t).cache
+		assert.NoError(t, cache.Ping(ctx))
+	})
+}
+
+// testClock is a manually advanced clock for MemoryCacheService
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Now()}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -7,6 +7,7 @@ import (
 
 	"DevelopsToday/config"
 
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -17,6 +18,7 @@ const (
 	CacheTypeRedis     CacheType = "redis"
 	CacheTypeMemcached CacheType = "memcached"
 	CacheTypeMemory    CacheType = "memory"
+	CacheTypeTiered    CacheType = "tiered"
 )
 
 // CacheFactory creates cache services based on configuration
@@ -36,6 +38,8 @@ func (f *CacheFactory) CreateCacheService(cacheType CacheType, cfg *config.Confi
 		return f.createMemcachedCache(cfg)
 	case CacheTypeMemory:
 		return f.createMemoryCache(cfg)
+	case CacheTypeTiered:
+		return f.createTieredCache(cfg)
 	default:
 		return nil, fmt.Errorf("unsupported cache type: %s", cacheType)
 	}
@@ -43,6 +47,37 @@ func (f *CacheFactory) CreateCacheService(cacheType CacheType, cfg *config.Confi
 
 // createRedisCache creates a Redis cache service
 func (f *CacheFactory) createRedisCache(cfg *config.Config) (CacheService, error) {
+	client, err := f.connectRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisCacheService(client), nil
+}
+
+// createTieredCache creates an in-memory cache in front of Redis
+func (f *CacheFactory) createTieredCache(cfg *config.Config) (CacheService, error) {
+	client, err := f.connectRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := NewTieredCacheService(context.Background(), client, TieredCacheOptions{
+		LocalTTL:    cfg.Cache.LocalTTL,
+		NegativeTTL: cfg.Cache.NegativeTTL,
+		Channel:     cfg.Cache.InvalidationChannel,
+		Local:       memoryCacheOptions(cfg),
+	})
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// connectRedis creates a Redis client from the configuration and checks the connection
+func (f *CacheFactory) connectRedis(cfg *config.Config) (*redis.Client, error) {
 	// Extract host and port from Redis URL
 	redisAddr := cfg.Redis.URL
 	if len(redisAddr) > 8 && redisAddr[:8] == "redis://" {
@@ -64,19 +99,61 @@ func (f *CacheFactory) createRedisCache(cfg *config.Config) (CacheService, error
 	// Test connection
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return NewRedisCacheService(client), nil
+	return client, nil
 }
 
 // createMemcachedCache creates a Memcached cache service
 func (f *CacheFactory) createMemcachedCache(cfg *config.Config) (CacheService, error) {
-	// TODO: Implement when memcached support is needed
-	return nil, fmt.Errorf("memcached cache service not yet implemented")
+	if len(cfg.Memcached.Servers) == 0 {
+		return nil, fmt.Errorf("memcached servers are not configured")
+	}
+
+	client := memcache.New(cfg.Memcached.Servers...)
+	client.Timeout = cfg.Memcached.Timeout
+	client.MaxIdleConns = cfg.Memcached.MaxIdleConns
+
+	// Test connection
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Memcached: %w", err)
+	}
+
+	return NewMemcachedCacheService(client), nil
 }
 
 // createMemoryCache creates an in-memory cache service
-func (f *CacheFactory) createMemoryCache(_ *config.Config) (CacheService, error) {
-	return NewMemoryCacheService(), nil
+func (f *CacheFactory) createMemoryCache(cfg *config.Config) (CacheService, error) {
+	// The memory cache is the only store of security keys, so they are
+	// pinned. The tiered L1 may evict them, Redis still has them.
+	opts := memoryCacheOptions(cfg)
+	opts.Pinned = IsSecurityKey
+	opts.MaxPinnedEntries = cfg.Cache.MemoryMaxPinnedEntries
+	opts.MaxPinnedBytes = cfg.Cache.MemoryMaxPinnedBytes
+	cache, err := NewMemoryCacheServiceWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Cache.SnapshotPath != "" {
+		if _, err := cache.LoadSnapshot(cfg.Cache.SnapshotPath); err != nil {
+			cache.Close()
+			return nil, err
+		}
+	}
+
+	return cache, nil
+}
+
+// memoryCacheOptions returns the bounds shared by the memory cache and the
+// L1 of the tiered cache
+func memoryCacheOptions(cfg *config.Config) MemoryCacheOptions {
+	return MemoryCacheOptions{
+		MaxEntries: cfg.Cache.MemoryMaxEntries,
+		MaxBytes:   cfg.Cache.MemoryMaxBytes,
+		Eviction:   EvictionPolicy(cfg.Cache.MemoryEviction),
+		Shards:     cfg.Cache.MemoryShards,
+	}
 }
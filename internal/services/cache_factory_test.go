@@ -2,6 +2,7 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"DevelopsToday/config"
 
@@ -25,13 +26,19 @@ func TestCacheFactory(t *testing.T) {
 		assert.Nil(t, cache)
 	})
 
-	t.Run("CreateCacheService with memcached type should return error (not implemented)", func(t *testing.T) {
-		cfg := &config.Config{}
+	t.Run("CreateCacheService with memcached type should connect to configured servers", func(t *testing.T) {
+		server := runMemcachedStandIn(t)
+		cfg := &config.Config{
+			Memcached: config.Memcached{
+				Servers: []string{server.Addr()},
+				Timeout: time.Second,
+			},
+		}
 
 		cache, err := factory.CreateCacheService(CacheTypeMemcached, cfg)
-		assert.Error(t, err)
-		assert.Nil(t, cache)
-		assert.Contains(t, err.Error(), "not yet implemented")
+		assert.NoError(t, err)
+		assert.NotNil(t, cache)
+		cache.Close()
 	})
 
 	t.Run("CreateCacheService with invalid type should return error", func(t *testing.T) {
@@ -43,13 +50,27 @@ func TestCacheFactory(t *testing.T) {
 		assert.Contains(t, err.Error(), "unsupported cache type")
 	})
 
-	t.Run("createMemcachedCache should return not implemented error", func(t *testing.T) {
+	t.Run("createMemcachedCache should fail without servers", func(t *testing.T) {
 		cfg := &config.Config{}
 
 		cache, err := factory.createMemcachedCache(cfg)
 		assert.Error(t, err)
 		assert.Nil(t, cache)
-		assert.Contains(t, err.Error(), "not yet implemented")
+		assert.Contains(t, err.Error(), "memcached servers are not configured")
+	})
+
+	t.Run("createMemcachedCache should fail when servers are unreachable", func(t *testing.T) {
+		cfg := &config.Config{
+			Memcached: config.Memcached{
+				Servers: []string{"127.0.0.1:1"},
+				Timeout: time.Second,
+			},
+		}
+
+		cache, err := factory.createMemcachedCache(cfg)
+		assert.Error(t, err)
+		assert.Nil(t, cache)
+		assert.Contains(t, err.Error(), "failed to connect to Memcached")
 	})
 }
 
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"DevelopsToday/internal/models"
+)
+
+func catCacheKey(id uint) string {
+	return fmt.Sprintf("cat:%d", id)
+}
+
+func missionCacheKey(id uint) string {
+	return fmt.Sprintf("mission:%d", id)
+}
+
+// writeMarkTTL is how long a write stays visible to reads that loaded their
+// value before it, far longer than any load takes
+const writeMarkTTL = time.Minute
+
+// writeMarkKey holds a random mark that changes on every write of key
+func writeMarkKey(key string) string {
+	return key + ":written"
+}
+
+// readThrough returns the cached value for key, or loads it and stores it for ttl.
+// Cache failures never fail the call, the value is simply loaded again.
+//
+// A write that commits while the value is being loaded invalidates the key
+// before the loaded, already stale, value is stored. The write mark catches
+// that: when it changed between loading and storing, the value is dropped.
+func readThrough[T any](ctx context.Context, cache CacheService, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	var cached T
+	if err := cache.GetJSON(ctx, key, &cached); err == nil {
+		return cached, nil
+	}
+
+	before, markErr := writeMark(ctx, cache, key)
+	value, err := load()
+	if err != nil || markErr != nil {
+		return value, err
+	}
+
+	if err := cache.SetJSON(ctx, key, value, ttl); err != nil {
+		return value, nil
+	}
+	if after, err := writeMark(ctx, cache, key); err != nil || after != before {
+		_ = cache.Delete(ctx, key)
+	}
+	return value, nil
+}
+
+// writeMark returns the current write mark of key, "" when it has none
+func writeMark(ctx context.Context, cache CacheService, key string) (string, error) {
+	mark, err := cache.Get(ctx, writeMarkKey(key))
+	if errors.Is(err, ErrCacheMiss) {
+		return "", nil
+	}
+	return mark, err
+}
+
+// invalidate drops keys from the cache after a write has committed and
+// changes their write marks. Failures are ignored, stale entries still
+// expire after their TTL.
+func invalidate(ctx context.Context, cache CacheService, keys ...string) {
+	for _, key := range keys {
+		if mark, err := newTokenID(); err == nil {
+			_ = cache.Set(ctx, writeMarkKey(key), mark, writeMarkTTL)
+		}
+		_ = cache.Delete(ctx, key)
+	}
+}
+
+// CachedCat is a read-through cache in front of a CatContext. GetByID is
+// served from the cache, writes invalidate the affected entries. Lists are
+// always read from the store.
+type CachedCat struct {
+	CatContext
+	cache CacheService
+	ttl   time.Duration
+}
+
+// NewCachedCat wraps cats with a read-through cache. A ttl <= 0 disables
+// caching and returns cats unchanged.
+func NewCachedCat(cats CatContext, cache CacheService, ttl time.Duration) CatContext {
+	if ttl <= 0 {
+		return cats
+	}
+	return &CachedCat{CatContext: cats, cache: cache, ttl: ttl}
+}
+
+func (s *CachedCat) GetByID(ctx context.Context, id uint) (*models.Cat, error) {
+	return readThrough(ctx, s.cache, catCacheKey(id), s.ttl, func() (*models.Cat, error) {
+		return s.CatContext.GetByID(ctx, id)
+	})
+}
+
+func (s *CachedCat) UpdateSalary(ctx context.Context, id uint, salary float64) error {
+	err := s.CatContext.UpdateSalary(ctx, id, salary)
+	invalidate(ctx, s.cache, catCacheKey(id))
+	return err
+}
+
+func (s *CachedCat) DeleteByID(ctx context.Context, id uint) error {
+	err := s.CatContext.DeleteByID(ctx, id)
+	invalidate(ctx, s.cache, catCacheKey(id))
+	return err
+}
+
+// CachedMission is a read-through cache in front of a MissionContext.
+// GetByID is served from the cache, writes invalidate the affected entries.
+// Target changes are invalidated by CachedTarget. Lists are always read from
+// the store.
+type CachedMission struct {
+	MissionContext
+	cache CacheService
+	ttl   time.Duration
+}
+
+// NewCachedMission wraps missions with a read-through cache. A ttl <= 0
+// disables caching and returns missions unchanged.
+func NewCachedMission(missions MissionContext, cache CacheService, ttl time.Duration) MissionContext {
+	if ttl <= 0 {
+		return missions
+	}
+	return &CachedMission{MissionContext: missions, cache: cache, ttl: ttl}
+}
+
+func (s *CachedMission) AssignCat(ctx context.Context, missionID, catID uint) error {
+	err := s.MissionContext.AssignCat(ctx, missionID, catID)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
+
+func (s *CachedMission) MarkComplete(ctx context.Context, missionID uint) error {
+	err := s.MissionContext.MarkComplete(ctx, missionID)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
+
+func (s *CachedMission) GetByID(ctx context.Context, id uint) (*models.Mission, error) {
+	mission, err := readThrough(ctx, s.cache, missionCacheKey(id), s.ttl, func() (*models.Mission, error) {
+		return s.MissionContext.GetByID(ctx, id)
+	})
+	if mission != nil {
+		restoreTargetMissionIDs(mission)
+	}
+	return mission, err
+}
+
+func (s *CachedMission) DeleteByID(ctx context.Context, id uint) error {
+	err := s.MissionContext.DeleteByID(ctx, id)
+	invalidate(ctx, s.cache, missionCacheKey(id))
+	return err
+}
+
+// restoreTargetMissionIDs fills Target.MissionID, which is not part of the JSON
+// representation and is lost when a mission is read back from the cache
+func restoreTargetMissionIDs(m *models.Mission) {
+	for i := range m.Targets {
+		m.Targets[i].MissionID = m.ID
+	}
+}
+
+// CachedTarget invalidates cached missions whenever one of their targets
+// changes. Targets themselves are only read as part of their mission.
+type CachedTarget struct {
+	TargetContext
+	cache CacheService
+}
+
+// NewCachedTarget wraps targets so that target writes invalidate the missions
+// cached by CachedMission
+func NewCachedTarget(targets TargetContext, cache CacheService) TargetContext {
+	return &CachedTarget{TargetContext: targets, cache: cache}
+}
+
+func (s *CachedTarget) Add(ctx context.Context, missionID uint, t *models.Target) error {
+	err := s.TargetContext.Add(ctx, missionID, t)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
+
+func (s *CachedTarget) UpdateNotes(ctx context.Context, missionID, targetID uint, notes string) error {
+	err := s.TargetContext.UpdateNotes(ctx, missionID, targetID, notes)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
+
+func (s *CachedTarget) MarkComplete(ctx context.Context, missionID, targetID uint) error {
+	err := s.TargetContext.MarkComplete(ctx, missionID, targetID)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
+
+func (s *CachedTarget) DeleteByID(ctx context.Context, missionID, targetID uint) error {
+	err := s.TargetContext.DeleteByID(ctx, missionID, targetID)
+	invalidate(ctx, s.cache, missionCacheKey(missionID))
+	return err
+}
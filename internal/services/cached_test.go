@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo/mocks"
+)
+
+// countingCat counts the lookups that reach the wrapped CatContext
+type countingCat struct {
+	CatContext
+	getByID int
+	// loaded runs after a lookup has read the cat, before it is returned
+	loaded func()
+}
+
+func (c *countingCat) GetByID(ctx context.Context, id uint) (*models.Cat, error) {
+	c.getByID++
+	cat, err := c.CatContext.GetByID(ctx, id)
+	if c.loaded != nil {
+		c.loaded()
+	}
+	return cat, err
+}
+
+// countingMission counts the lookups that reach the wrapped MissionContext
+type countingMission struct {
+	MissionContext
+	getByID int
+}
+
+func (c *countingMission) GetByID(ctx context.Context, id uint) (*models.Mission, error) {
+	c.getByID++
+	return c.MissionContext.GetByID(ctx, id)
+}
+
+func TestCachedCat(t *testing.T) {
+	store := mocks.NewRepository()
+	cache := NewMemoryCacheService()
+	defer cache.Close()
+
+	inner := &countingCat{CatContext: NewCat(store.Cat())}
+	cats := NewCachedCat(inner, cache, time.Minute)
+	ctx := context.Background()
+
+	t.Run("GetByID should be served from cache after the first call", func(t *testing.T) {
+		first, err := cats.GetByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, err := cats.GetByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if inner.getByID != 1 {
+			t.Fatalf("Expected 1 lookup, got %d", inner.getByID)
+		}
+		if *first != *second {
+			t.Fatalf("Expected cached cat %+v, got %+v", first, second)
+		}
+	})
+
+	t.Run("UpdateSalary should invalidate the cached cat", func(t *testing.T) {
+		if err := cats.UpdateSalary(ctx, 1, 9999); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cat, err := cats.GetByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cat.Salary != 9999 {
+			t.Fatalf("Expected salary 9999, got %f", cat.Salary)
+		}
+	})
+
+	t.Run("a write during a lookup should not leave the stale cat cached", func(t *testing.T) {
+		// The salary changes after the lookup has read the cat
+		inner.loaded = func() {
+			inner.loaded = nil
+			if err := cats.UpdateSalary(ctx, 2, 4321); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		stale, err := cats.GetByID(ctx, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if stale.Salary == 4321 {
+			t.Fatal("Expected the lookup to return the cat read before the write")
+		}
+
+		cat, err := cats.GetByID(ctx, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cat.Salary != 4321 {
+			t.Fatalf("Expected salary 4321, got %f", cat.Salary)
+		}
+	})
+
+	t.Run("GetByID errors should not be cached", func(t *testing.T) {
+		before := inner.getByID
+		_, _ = cats.GetByID(ctx, 999)
+		_, _ = cats.GetByID(ctx, 999)
+
+		if inner.getByID != before+2 {
+			t.Fatalf("Expected both lookups to reach the service, got %d", inner.getByID-before)
+		}
+	})
+
+	t.Run("zero TTL should disable caching", func(t *testing.T) {
+		plain := NewCat(store.Cat())
+		if NewCachedCat(plain, cache, 0) != plain {
+			t.Fatal("Expected the service to be returned unchanged")
+		}
+	})
+}
+
+func TestCachedMission(t *testing.T) {
+	store := mocks.NewRepository()
+	cache := NewMemoryCacheService()
+	defer cache.Close()
+
+	inner := &countingMission{MissionContext: NewMission(store)}
+	missions := NewCachedMission(inner, cache, time.Minute)
+	targets := NewCachedTarget(NewTarget(store, nil), cache)
+	ctx := context.Background()
+
+	t.Run("GetByID should be served from cache after the first call", func(t *testing.T) {
+		if _, err := missions.GetByID(ctx, 1); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		mission, err := missions.GetByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if inner.getByID != 1 {
+			t.Fatalf("Expected 1 lookup, got %d", inner.getByID)
+		}
+		for _, target := range mission.Targets {
+			if target.MissionID != 1 {
+				t.Fatalf("Expected target MissionID 1, got %d", target.MissionID)
+			}
+		}
+	})
+
+	t.Run("target changes should invalidate the cached mission", func(t *testing.T) {
+		if err := targets.UpdateNotes(ctx, 1, 1, "Fresh notes"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		mission, err := missions.GetByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if inner.getByID != 2 {
+			t.Fatalf("Expected mission to be reloaded, got %d lookups", inner.getByID)
+		}
+
+		found := false
+		for _, target := range mission.Targets {
+			if target.ID == 1 && target.Notes == "Fresh notes" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("Expected updated notes in the reloaded mission")
+		}
+	})
+
+	t.Run("MarkComplete should invalidate the cached mission", func(t *testing.T) {
+		if _, err := missions.GetByID(ctx, 3); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		before := inner.getByID
+
+		_ = missions.MarkComplete(ctx, 3)
+
+		if _, err := missions.GetByID(ctx, 3); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if inner.getByID != before+1 {
+			t.Fatalf("Expected mission to be reloaded, got %d lookups", inner.getByID-before)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 type CatContext interface {
 	Create(ctx context.Context, c *models.Cat) error
 	GetAll(ctx context.Context) ([]models.Cat, error)
+	List(ctx context.Context, filter repo.CatFilter) ([]models.Cat, int64, error)
 	GetByID(ctx context.Context, id uint) (*models.Cat, error)
 	UpdateSalary(ctx context.Context, id uint, salary float64) error
 	DeleteByID(ctx context.Context, id uint) error
@@ -31,6 +32,10 @@ func (s *Cat) GetAll(ctx context.Context) ([]models.Cat, error) {
 	return s.repo.FindAll(ctx)
 }
 
+func (s *Cat) List(ctx context.Context, filter repo.CatFilter) ([]models.Cat, int64, error) {
+	return s.repo.FindPage(ctx, filter)
+}
+
 func (s *Cat) GetByID(ctx context.Context, id uint) (*models.Cat, error) {
 	return s.repo.FindByID(ctx, id)
 }
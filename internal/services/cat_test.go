@@ -2,12 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
 	"DevelopsToday/internal/repo/mocks"
-
-	"gorm.io/gorm"
 )
 
 func TestCatService(t *testing.T) {
@@ -66,8 +66,8 @@ func TestCatService(t *testing.T) {
 
 	t.Run("GetByID should return error for non-existing cat", func(t *testing.T) {
 		_, err := catService.GetByID(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 
@@ -89,8 +89,8 @@ func TestCatService(t *testing.T) {
 
 	t.Run("UpdateSalary should return error for non-existing cat", func(t *testing.T) {
 		err := catService.UpdateSalary(ctx, 999, 2000)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 
@@ -115,15 +115,15 @@ func TestCatService(t *testing.T) {
 
 		// Verify cat was deleted
 		_, err = catService.GetByID(ctx, cat.ID)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 
 	t.Run("DeleteByID should return error for non-existing cat", func(t *testing.T) {
 		err := catService.DeleteByID(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
 		}
 	})
 }
@@ -0,0 +1,12 @@
+package services
+
+import "errors"
+
+// Business rule violations reported by the services. Not found and conflict
+// errors come from the repo package (repo.ErrNotFound, repo.ErrCatBusy, ...).
+var (
+	ErrInvalidTargetCount = errors.New("mission must have between 1 and 3 targets")
+	ErrTargetsIncomplete  = errors.New("all targets must be completed before completing mission")
+	ErrMissionAssigned    = errors.New("cannot delete assigned mission")
+	ErrTargetComplete     = errors.New("target is completed")
+)
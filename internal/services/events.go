@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is a domain event raised by services
+type Event interface {
+	EventName() string
+}
+
+// EventHandler reacts to a published domain event
+type EventHandler func(ctx context.Context, event Event)
+
+// EventPublisher delivers domain events to subscribers
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+const EventMissionCompleted = "mission.completed"
+
+// MissionCompleted is raised when a mission is completed automatically
+// because its last open target was completed
+type MissionCompleted struct {
+	CompletedAt time.Time
+	CatID       *uint
+	MissionID   uint
+}
+
+func (MissionCompleted) EventName() string {
+	return EventMissionCompleted
+}
+
+const EventRefreshTokenReused = "auth.refresh_token_reused"
+
+// RefreshTokenReused is raised when a rotated-out refresh token is presented
+// again and its session (token family) is revoked
+type RefreshTokenReused struct {
+	DetectedAt time.Time
+	UserID     uint
+	SessionID  string
+	TokenID    string
+	UserAgent  string
+	IP         string
+}
+
+func (RefreshTokenReused) EventName() string {
+	return EventRefreshTokenReused
+}
+
+// EventBus is an in-process synchronous EventPublisher
+type EventBus struct {
+	handlers map[string][]EventHandler
+	mutex    sync.RWMutex
+}
+
+// NewEventBus creates a new in-process event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[string][]EventHandler),
+	}
+}
+
+// Subscribe registers a handler for the named event
+func (b *EventBus) Subscribe(name string, handler EventHandler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish calls every handler subscribed to the event
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	b.mutex.RLock()
+	handlers := b.handlers[event.EventName()]
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
+
+// noopPublisher discards all events
+type noopPublisher struct{}
+
+func (noopPublisher) Publish(context.Context, Event) {}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"DevelopsToday/config"
+)
+
+// Registration modes
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+)
+
+var (
+	// ErrInvitationRequired is returned when registration is by invitation
+	// only and no code was given
+	ErrInvitationRequired = errors.New("invitation code required")
+	// ErrInvalidInvitation is returned for unknown, used or expired codes
+	ErrInvalidInvitation = errors.New("invalid invitation code")
+)
+
+const defaultInviteTTL = 72 * time.Hour
+
+// Invitation is a single-use code that allows one registration
+type Invitation struct {
+	Code      string    `json:"code"`
+	CreatedBy uint      `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// InvitationService issues invitation codes and admits new users. Only the
+// hash of a code is stored, like refresh tokens.
+type InvitationService struct {
+	cache CacheService
+	cfg   config.Registration
+	rbac  *RBAC
+	// mutex makes redeeming a code a single step on caches without
+	// CacheAtomic, where it only holds within this instance
+	mutex sync.Mutex
+}
+
+// NewInvitationService creates the registration policy from cfg
+func NewInvitationService(cfg config.Registration, cache CacheService, rbac *RBAC) (*InvitationService, error) {
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = RegistrationOpen
+	case RegistrationOpen, RegistrationInvite:
+	default:
+		return nil, fmt.Errorf("unsupported registration mode: %s", cfg.Mode)
+	}
+	if cfg.InviteTTL <= 0 {
+		cfg.InviteTTL = defaultInviteTTL
+	}
+
+	return &InvitationService{cache: cache, cfg: cfg, rbac: rbac}, nil
+}
+
+func invitationKey(code string) string {
+	return fmt.Sprintf("invitation:%s", hashToken(code))
+}
+
+// Create issues a new invitation code on behalf of createdBy
+func (s *InvitationService) Create(ctx context.Context, createdBy uint) (*Invitation, error) {
+	code, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		Code:      code,
+		CreatedBy: createdBy,
+		ExpiresAt: time.Now().Add(s.cfg.InviteTTL),
+	}
+
+	stored := Invitation{CreatedBy: invitation.CreatedBy, ExpiresAt: invitation.ExpiresAt}
+	if err := s.cache.SetJSON(ctx, invitationKey(code), stored, s.cfg.InviteTTL); err != nil {
+		return nil, fmt.Errorf("failed to store invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// Admission lets one new user register
+type Admission struct {
+	// Role is the role the new user gets
+	Role string
+
+	code       string
+	invitation *Invitation
+}
+
+// Admit decides whether a new user may register and returns the role they
+// get. Public registration always gets the default, least privileged, role.
+// In invite mode the code is used up, Release puts it back when the
+// registration fails after all.
+func (s *InvitationService) Admit(ctx context.Context, code string) (*Admission, error) {
+	admission := &Admission{Role: s.rbac.DefaultRole()}
+	if s.cfg.Mode == RegistrationInvite {
+		if code == "" {
+			return nil, ErrInvitationRequired
+		}
+		invitation, err := s.redeem(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		admission.code, admission.invitation = code, invitation
+	}
+	return admission, nil
+}
+
+// Release makes the invitation used by admission valid again, for the time
+// it had left
+func (s *InvitationService) Release(ctx context.Context, admission *Admission) error {
+	if admission.invitation == nil {
+		return nil
+	}
+	ttl := time.Until(admission.invitation.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.cache.SetJSON(ctx, invitationKey(admission.code), admission.invitation, ttl); err != nil {
+		return fmt.Errorf("failed to release invitation: %w", err)
+	}
+	return nil
+}
+
+func (s *InvitationService) redeem(ctx context.Context, code string) (*Invitation, error) {
+	var invitation Invitation
+	err := takeJSON(ctx, s.cache, &s.mutex, invitationKey(code), &invitation)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem invitation: %w", err)
+	}
+
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	return &invitation, nil
+}
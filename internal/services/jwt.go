@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"DevelopsToday/config"
@@ -10,16 +11,34 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenType tells access tokens and refresh tokens apart
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
 type JWTService struct {
 	cfg    *config.Config
 	cache  CacheService
-	secret []byte
+	events EventPublisher
+	// accessKeys sign access tokens, refreshKeys refresh tokens. They are
+	// the same set unless a separate refresh secret is configured.
+	accessKeys  *jwtKeySet
+	refreshKeys *jwtKeySet
+	// sessionsMutex serializes updates of the per-user session index
+	sessionsMutex sync.Mutex
 }
 
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// SessionID identifies the session the token belongs to
+	SessionID string `json:"sid,omitempty"`
+	// Type is the kind of token, checked on every validation
+	Type TokenType `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
@@ -28,129 +47,259 @@ type TokenPair struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func NewJWTService(cfg *config.Config, cacheService CacheService) *JWTService {
+// NewJWTService creates the JWT service. events receives security events
+// such as refresh token reuse and may be nil.
+func NewJWTService(cfg *config.Config, cacheService CacheService, events EventPublisher) (*JWTService, error) {
+	if events == nil {
+		events = noopPublisher{}
+	}
+
+	accessKeys, err := loadAccessKeys(cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+
+	refreshKeys := accessKeys
+	if cfg.JWT.RefreshSecret != "" {
+		refreshKeys = newJWTKeySet(newHMACKey([]byte(cfg.JWT.RefreshSecret)))
+	}
+
 	return &JWTService{
-		cfg:    cfg,
-		cache:  cacheService,
-		secret: []byte(cfg.JWT.Secret),
+		cfg:         cfg,
+		cache:       cacheService,
+		events:      events,
+		accessKeys:  accessKeys,
+		refreshKeys: refreshKeys,
+	}, nil
+}
+
+// audience returns the aud claim of tokens of type t. Refresh tokens get
+// their own audience so that other services never accept them.
+func (j *JWTService) audience(t TokenType) string {
+	if t == TokenTypeRefresh {
+		return j.cfg.JWT.RefreshAudience
+	}
+	return j.cfg.JWT.AccessAudience
+}
+
+// keys returns the key set of tokens of type t
+func (j *JWTService) keys(t TokenType) *jwtKeySet {
+	if t == TokenTypeRefresh {
+		return j.refreshKeys
+	}
+	return j.accessKeys
+}
+
+// JWKS returns the public keys access tokens are verified with, so that other
+// services can verify them. It is empty when tokens are signed with HMAC.
+func (j *JWTService) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range j.accessKeys.ordered {
+		if _, shared := key.method.(*jwt.SigningMethodHMAC); shared {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, key.jwk())
 	}
+	return jwks
+}
+
+func (j *JWTService) accessTTL() time.Duration {
+	return time.Duration(j.cfg.JWT.AccessTokenTTL) * time.Second
 }
 
-// GenerateTokenPair generates access and refresh tokens
-func (j *JWTService) GenerateTokenPair(userID uint, username, role string) (*TokenPair, error) {
-	// Generate access token
-	accessToken, err := j.generateToken(userID, username, role, time.Duration(j.cfg.JWT.AccessTokenTTL)*time.Second)
+func (j *JWTService) refreshTTL() time.Duration {
+	return time.Duration(j.cfg.JWT.RefreshTokenTTL) * time.Second
+}
+
+// GenerateTokenPair starts a new session for device and returns its tokens
+func (j *JWTService) GenerateTokenPair(userID uint, username, role string, device DeviceInfo) (*TokenPair, error) {
+	sessionID, err := newTokenID()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, err
 	}
 
-	// Generate refresh token
-	refreshToken, err := j.generateToken(userID, username, role, time.Duration(j.cfg.JWT.RefreshTokenTTL)*time.Second)
+	now := time.Now()
+	session := &Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Device:     device.Device,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	tokens, err := j.issueTokenPair(session, username, role)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
+	}
+	if err := j.saveSession(context.Background(), session); err != nil {
+		return nil, err
 	}
 
-	// Store refresh token in cache
-	ctx := context.Background()
-	refreshKey := fmt.Sprintf("refresh_token:%d", userID)
-	err = j.cache.Set(ctx, refreshKey, refreshToken, time.Duration(j.cfg.JWT.RefreshTokenTTL)*time.Second)
+	if err := j.addSession(context.Background(), userID, sessionID); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// issueTokenPair generates tokens for session and records the hash of its new
+// refresh token in it. The caller stores the session.
+func (j *JWTService) issueTokenPair(session *Session, username, role string) (*TokenPair, error) {
+	// Generate access token, its jti is what the blacklist stores
+	accessTokenID, err := newTokenID()
 	if err != nil {
-		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+		return nil, err
+	}
+	accessToken, err := j.generateToken(TokenTypeAccess, session.UserID, username, role, session.ID, accessTokenID, j.accessTTL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	// Generate refresh token with a fresh jti, so every rotation differs
+	refreshTokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := j.generateToken(TokenTypeRefresh, session.UserID, username, role, session.ID, refreshTokenID, j.refreshTTL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	session.TokenHash = hashToken(refreshToken)
+	session.ExpiresAt = time.Now().Add(j.refreshTTL())
+
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
 }
 
-// generateToken creates a JWT token
-func (j *JWTService) generateToken(userID uint, username, role string, duration time.Duration) (string, error) {
+// generateToken creates a JWT token of type t
+func (j *JWTService) generateToken(t TokenType, userID uint, username, role, sessionID, tokenID string, duration time.Duration) (string, error) {
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
+		Type:      t,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    j.cfg.App.Name,
 		},
 	}
+	if audience := j.audience(t); audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secret)
+	key := j.keys(t).signing
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.sign)
 }
 
-// ValidateToken validates and parses a JWT token
-func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates and parses a JWT token, which must be of the
+// expected type
+func (j *JWTService) ValidateToken(tokenString string, expected TokenType) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		key, err := j.keys(expected).lookup(token)
+		if err != nil {
+			return nil, err
 		}
-		return j.secret, nil
-	})
+		return key.verify, nil
+	}, jwt.WithAudience(j.audience(expected)), jwt.WithIssuer(j.cfg.App.Name))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// With a shared key and audience only the claim tells the types apart
+	if claims.Type != expected {
+		return nil, fmt.Errorf("invalid token type: expected %s, got %q", expected, claims.Type)
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
 
-// RefreshToken refreshes an access token using a refresh token
-func (j *JWTService) RefreshToken(refreshToken string) (*TokenPair, error) {
+// RefreshToken rotates a refresh token: it returns a new pair and the
+// presented token stops being valid. client describes the caller for the
+// audit trail when a rotated-out token is reused.
+func (j *JWTService) RefreshToken(refreshToken string, client DeviceInfo) (*TokenPair, error) {
 	// Validate refresh token
-	claims, err := j.ValidateToken(refreshToken)
+	claims, err := j.ValidateToken(refreshToken, TokenTypeRefresh)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// Check if refresh token exists in cache
 	ctx := context.Background()
-	refreshKey := fmt.Sprintf("refresh_token:%d", claims.UserID)
-	storedToken, err := j.cache.Get(ctx, refreshKey)
+
+	// Logging out everywhere may have left the session in place, e.g. when
+	// the watermark was set on its own
+	revoked, err := j.issuedBeforeWatermark(ctx, claims)
 	if err != nil {
-		return nil, fmt.Errorf("refresh token not found: %w", err)
+		return nil, err
 	}
-
-	if storedToken != refreshToken {
-		return nil, fmt.Errorf("refresh token mismatch")
+	if revoked {
+		return nil, ErrTokenRevoked
 	}
 
-	// Generate new token pair
-	return j.GenerateTokenPair(claims.UserID, claims.Username, claims.Role)
-}
+	// Revocation must not race with the session being stored again below
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
 
-// RevokeToken revokes a refresh token
-func (j *JWTService) RevokeToken(userID uint) error {
-	ctx := context.Background()
-	refreshKey := fmt.Sprintf("refresh_token:%d", userID)
-	return j.cache.Delete(ctx, refreshKey)
-}
+	// Check the token belongs to a live session
+	session, stored, err := j.loadStoredSession(ctx, claims.UserID, claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token not found: %w", err)
+	}
+
+	// The signature proves we issued the token for this session, so a
+	// mismatch is an older token of the family being replayed
+	if !session.matchesToken(refreshToken) {
+		return nil, j.revokeReusedSession(ctx, claims, client)
+	}
 
-// BlacklistToken adds a token to the blacklist
-func (j *JWTService) BlacklistToken(tokenString string) error {
-	claims, err := j.ValidateToken(tokenString)
+	session.LastUsedAt = time.Now()
+	tokens, err := j.issueTokenPair(session, claims.Username, claims.Role)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ctx := context.Background()
-	blacklistKey := fmt.Sprintf("blacklist:%s", tokenString)
-	ttl := time.Until(claims.ExpiresAt.Time)
+	// Another instance may have rotated the same token since it was loaded,
+	// then the token was presented twice
+	replaced, err := j.replaceSession(ctx, session, stored)
+	if err != nil {
+		return nil, err
+	}
+	if !replaced {
+		return nil, j.revokeReusedSession(ctx, claims, client)
+	}
 
-	return j.cache.Set(ctx, blacklistKey, "1", ttl)
+	return tokens, nil
 }
 
-// IsTokenBlacklisted checks if a token is blacklisted
-func (j *JWTService) IsTokenBlacklisted(tokenString string) bool {
-	ctx := context.Background()
-	blacklistKey := fmt.Sprintf("blacklist:%s", tokenString)
-	exists, err := j.cache.Exists(ctx, blacklistKey)
-	return err == nil && exists
+// revokeReusedSession logs out the session of a reused refresh token, which
+// also invalidates its access tokens, and reports the reuse
+func (j *JWTService) revokeReusedSession(ctx context.Context, claims *Claims, client DeviceInfo) error {
+	if err := j.deleteSession(ctx, claims.UserID, claims.SessionID); err != nil {
+		return err
+	}
+	j.events.Publish(ctx, RefreshTokenReused{
+		DetectedAt: time.Now(),
+		UserID:     claims.UserID,
+		SessionID:  claims.SessionID,
+		TokenID:    claims.ID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+	})
+	return ErrRefreshTokenReused
 }
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"DevelopsToday/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported values of config.JWT.SigningAlgorithm
+const (
+	SigningHS256 = "HS256"
+	SigningRS256 = "RS256"
+	SigningES256 = "ES256"
+	SigningEdDSA = "EdDSA"
+)
+
+// jwtKey is a key tokens are signed or verified with. For HMAC sign and
+// verify are the same secret, otherwise sign is nil on verification-only keys.
+type jwtKey struct {
+	id     string
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// jwtKeySet signs new tokens with one key and accepts tokens signed by any
+// of its keys, so that tokens issued before a key rotation stay valid
+type jwtKeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+	// ordered keeps the keys in configuration order for the JWKS
+	ordered []*jwtKey
+}
+
+func newJWTKeySet(signing *jwtKey, verification ...*jwtKey) *jwtKeySet {
+	set := &jwtKeySet{signing: signing, keys: make(map[string]*jwtKey)}
+	for _, key := range append([]*jwtKey{signing}, verification...) {
+		if _, exists := set.keys[key.id]; exists {
+			continue
+		}
+		set.keys[key.id] = key
+		set.ordered = append(set.ordered, key)
+	}
+	return set
+}
+
+// lookup returns the key a token claims to be signed with. Tokens without a
+// kid were issued before key IDs existed and can only match the signing key.
+func (s *jwtKeySet) lookup(token *jwt.Token) (*jwtKey, error) {
+	key := s.signing
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		if key, ok = s.keys[id]; !ok {
+			return nil, fmt.Errorf("unknown key id: %v", kid)
+		}
+	}
+
+	// Never let the token choose the algorithm, e.g. HS256 with a public key
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key, nil
+}
+
+// loadAccessKeys builds the key set of access tokens from the configuration
+func loadAccessKeys(cfg config.JWT) (*jwtKeySet, error) {
+	var signing *jwtKey
+	switch cfg.SigningAlgorithm {
+	case "", SigningHS256:
+		signing = newHMACKey([]byte(cfg.Secret))
+	case SigningRS256, SigningES256, SigningEdDSA:
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.SigningAlgorithm)
+		}
+		key, err := loadSigningKey(cfg.SigningAlgorithm, cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signing = key
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm: %s", cfg.SigningAlgorithm)
+	}
+
+	verification := make([]*jwtKey, 0, len(cfg.PublicKeyFiles))
+	for _, path := range cfg.PublicKeyFiles {
+		key, err := loadVerificationKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+
+	return newJWTKeySet(signing, verification...), nil
+}
+
+// newHMACKey creates an HS256 key from a shared secret
+func newHMACKey(secret []byte) *jwtKey {
+	sum := sha256.Sum256(append([]byte("kid:"), secret...))
+	return &jwtKey{
+		id:     base64.RawURLEncoding.EncodeToString(sum[:12]),
+		method: jwt.SigningMethodHS256,
+		sign:   secret,
+		verify: secret,
+	}
+}
+
+// loadSigningKey reads a PEM private key for algorithm
+func loadSigningKey(algorithm, path string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type in %s", path)
+	}
+
+	key, err := newPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if key.method.Alg() != algorithm {
+		return nil, fmt.Errorf("%s holds a %s key, JWT_SIGNING_ALGORITHM is %s", path, key.method.Alg(), algorithm)
+	}
+
+	key.sign = private
+	return key, nil
+}
+
+// loadVerificationKey reads a PEM public key, or a certificate, that
+// previously issued tokens were signed with
+func loadVerificationKey(path string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var public interface{}
+	if block.Type == "CERTIFICATE" {
+		cert, certErr := x509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return nil, fmt.Errorf("failed to parse certificate %s: %w", path, certErr)
+		}
+		public = cert.PublicKey
+	} else {
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+		}
+	}
+
+	key, err := newPublicKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newPublicKey picks the signing method from the key type and derives the
+// key ID from the RFC 7638 thumbprint
+func newPublicKey(public interface{}) (*jwtKey, error) {
+	key := &jwtKey{verify: public}
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+		key.method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	jwk := key.jwk()
+	thumbprint, err := jwk.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.id = thumbprint
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
+
+// parsePrivateKey accepts PKCS #8 as well as the PKCS #1 and SEC 1 formats
+// written by older openssl versions
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk returns the public part of an asymmetric key
+func (k *jwtKey) jwk() JWK {
+	jwk := JWK{Use: "sig", Alg: k.method.Alg(), Kid: k.id}
+	switch public := k.verify.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint: the SHA-256 of the
+// required members in lexicographic order
+func (j JWK) thumbprint() (string, error) {
+	var members interface{}
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
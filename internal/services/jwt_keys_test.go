@@ -0,0 +1,216 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestKeyPair generates a key of the given algorithm and writes its
+// private and public halves as PEM files, returning their paths
+func writeTestKeyPair(t *testing.T, algorithm string) (string, string) {
+	t.Helper()
+
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case SigningRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case SigningES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case SigningEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported algorithm %s", algorithm)
+	}
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600))
+	return privatePath, publicPath
+}
+
+func newTestKeyedJWTService(t *testing.T, jwtCfg config.JWT) (*JWTService, error) {
+	t.Helper()
+
+	jwtCfg.AccessTokenTTL = 900
+	jwtCfg.RefreshTokenTTL = 604800
+	cfg := &config.Config{
+		JWT: jwtCfg,
+		App: config.App{Name: "spy-cats-api"},
+	}
+
+	cache := NewMemoryCacheService()
+	t.Cleanup(func() { cache.Close() })
+	return NewJWTService(cfg, cache, nil)
+}
+
+func TestJWTSigningKeys(t *testing.T) {
+	device := DeviceInfo{Device: "laptop"}
+
+	for _, algorithm := range []string{SigningRS256, SigningES256, SigningEdDSA} {
+		t.Run(algorithm+" tokens should round-trip", func(t *testing.T) {
+			privatePath, _ := writeTestKeyPair(t, algorithm)
+			jwtService, err := newTestKeyedJWTService(t, config.JWT{
+				Secret:           "test-secret-key-for-jwt-testing",
+				SigningAlgorithm: algorithm,
+				PrivateKeyFile:   privatePath,
+			})
+			require.NoError(t, err)
+
+			tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+			require.NoError(t, err)
+
+			claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+			require.NoError(t, err)
+			assert.Equal(t, uint(1), claims.UserID)
+
+			_, err = jwtService.RefreshToken(tokens.RefreshToken, device)
+			require.NoError(t, err)
+
+			// The kid header names the JWKS entry to verify with
+			parsed, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, parsed.Header["alg"])
+
+			jwks := jwtService.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, jwks.Keys[0].Kid, parsed.Header["kid"])
+			assert.Equal(t, algorithm, jwks.Keys[0].Alg)
+			assert.Equal(t, "sig", jwks.Keys[0].Use)
+		})
+	}
+
+	t.Run("tokens signed before a key rotation should stay valid", func(t *testing.T) {
+		oldPrivate, oldPublic := writeTestKeyPair(t, SigningES256)
+		oldService, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningES256,
+			PrivateKeyFile:   oldPrivate,
+		})
+		require.NoError(t, err)
+		oldTokens, err := oldService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+
+		newPrivate, _ := writeTestKeyPair(t, SigningRS256)
+		rotated, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningRS256,
+			PrivateKeyFile:   newPrivate,
+			PublicKeyFiles:   []string{oldPublic},
+		})
+		require.NoError(t, err)
+
+		_, err = rotated.ValidateToken(oldTokens.AccessToken, TokenTypeAccess)
+		assert.NoError(t, err)
+		assert.Len(t, rotated.JWKS().Keys, 2)
+
+		// Without the old public key the token can no longer be verified
+		unrotated, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningRS256,
+			PrivateKeyFile:   newPrivate,
+		})
+		require.NoError(t, err)
+		_, err = unrotated.ValidateToken(oldTokens.AccessToken, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("HS256 tokens signed with the public key should be rejected", func(t *testing.T) {
+		privatePath, publicPath := writeTestKeyPair(t, SigningRS256)
+		jwtService, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningRS256,
+			PrivateKeyFile:   privatePath,
+		})
+		require.NoError(t, err)
+
+		publicPEM, err := os.ReadFile(publicPath)
+		require.NoError(t, err)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+			UserID: 1,
+			Type:   TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "spy-cats-api",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		forged.Header["kid"] = jwtService.JWKS().Keys[0].Kid
+		token, err := forged.SignedString(publicPEM)
+		require.NoError(t, err)
+
+		_, err = jwtService.ValidateToken(token, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("tokens with an unknown key id should be rejected", func(t *testing.T) {
+		otherPrivate, _ := writeTestKeyPair(t, SigningEdDSA)
+		otherService, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningEdDSA,
+			PrivateKeyFile:   otherPrivate,
+		})
+		require.NoError(t, err)
+		tokens, err := otherService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+
+		privatePath, _ := writeTestKeyPair(t, SigningEdDSA)
+		jwtService, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningEdDSA,
+			PrivateKeyFile:   privatePath,
+		})
+		require.NoError(t, err)
+
+		_, err = jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("misconfigured keys should fail at startup", func(t *testing.T) {
+		rsaPrivate, _ := writeTestKeyPair(t, SigningRS256)
+
+		_, err := newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningES256,
+			PrivateKeyFile:   rsaPrivate,
+		})
+		assert.Error(t, err, "key type must match the algorithm")
+
+		_, err = newTestKeyedJWTService(t, config.JWT{SigningAlgorithm: SigningRS256})
+		assert.Error(t, err, "private key file is required")
+
+		_, err = newTestKeyedJWTService(t, config.JWT{
+			SigningAlgorithm: SigningRS256,
+			PrivateKeyFile:   filepath.Join(t.TempDir(), "missing.pem"),
+		})
+		assert.Error(t, err, "private key file must exist")
+
+		_, err = newTestKeyedJWTService(t, config.JWT{SigningAlgorithm: "none"})
+		assert.Error(t, err, "unsupported algorithm")
+	})
+
+	t.Run("JWKS should be empty for HS256", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		jwks := jwtService.JWKS()
+		assert.NotNil(t, jwks.Keys)
+		assert.Empty(t, jwks.Keys)
+	})
+}
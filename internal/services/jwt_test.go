@@ -6,6 +6,7 @@ import (
 
 	"DevelopsToday/config"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -30,22 +31,23 @@ func TestJWTService(t *testing.T) {
 
 	// Create memory cache for testing
 	cache := NewMemoryCacheService()
-	jwtService := NewJWTService(cfg, cache)
+	jwtService, err := NewJWTService(cfg, cache, nil)
+	require.NoError(t, err)
 
 	testUserID := uint(1)
 
 	t.Run("GenerateTokenPair should create valid tokens", func(t *testing.T) {
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 		assert.NotEmpty(t, tokens.AccessToken)
 		assert.NotEmpty(t, tokens.RefreshToken)
 	})
 
 	t.Run("ValidateToken should validate correct token", func(t *testing.T) {
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
-		claims, err := jwtService.ValidateToken(tokens.AccessToken)
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
 		require.NoError(t, err)
 		assert.Equal(t, testUserID, claims.UserID)
 		assert.Equal(t, testUsername, claims.Username)
@@ -54,18 +56,18 @@ func TestJWTService(t *testing.T) {
 
 	t.Run("ValidateToken should reject invalid token", func(t *testing.T) {
 		invalidToken := "invalid.token.here"
-		_, err := jwtService.ValidateToken(invalidToken)
+		_, err := jwtService.ValidateToken(invalidToken, TokenTypeAccess)
 		assert.Error(t, err)
 	})
 
 	t.Run("RefreshToken should create new tokens from valid refresh token", func(t *testing.T) {
-		originalTokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		originalTokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
 		// Wait a bit to ensure different timestamps
 		time.Sleep(time.Second * 1)
 
-		newTokens, err := jwtService.RefreshToken(originalTokens.RefreshToken)
+		newTokens, err := jwtService.RefreshToken(originalTokens.RefreshToken, DeviceInfo{})
 		require.NoError(t, err)
 		assert.NotEmpty(t, newTokens.AccessToken)
 		assert.NotEmpty(t, newTokens.RefreshToken)
@@ -81,36 +83,38 @@ func TestJWTService(t *testing.T) {
 
 	t.Run("RefreshToken should reject invalid refresh token", func(t *testing.T) {
 		invalidToken := "invalid.refresh.token"
-		_, err := jwtService.RefreshToken(invalidToken)
+		_, err := jwtService.RefreshToken(invalidToken, DeviceInfo{})
 		assert.Error(t, err)
 	})
 
-	t.Run("RevokeToken should remove refresh token from cache", func(t *testing.T) {
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+	t.Run("RevokeAllSessions should remove refresh token from cache", func(t *testing.T) {
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
 		// Revoke the token
-		err = jwtService.RevokeToken(testUserID)
+		err = jwtService.RevokeAllSessions(testUserID)
 		require.NoError(t, err)
 
 		// Try to refresh with revoked token should fail
-		_, err = jwtService.RefreshToken(tokens.RefreshToken)
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
 		assert.Error(t, err)
 	})
 
 	t.Run("BlacklistToken should prevent token usage", func(t *testing.T) {
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
 		// Token should not be blacklisted initially
-		assert.False(t, jwtService.IsTokenBlacklisted(tokens.AccessToken))
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.NoError(t, err)
 
 		// Blacklist the token
 		err = jwtService.BlacklistToken(tokens.AccessToken)
 		require.NoError(t, err)
 
 		// Token should now be blacklisted
-		assert.True(t, jwtService.IsTokenBlacklisted(tokens.AccessToken))
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
 	})
 
 	t.Run("Different secret keys should not validate tokens", func(t *testing.T) {
@@ -125,13 +129,15 @@ func TestJWTService(t *testing.T) {
 			},
 		}
 
-		differentSecretService := NewJWTService(differentCfg, cache)
+		differentSecretService, err := NewJWTService(differentCfg, cache, nil)
 
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		require.NoError(t, err)
+
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
 		// Token created with original service should not validate with different secret
-		_, err = differentSecretService.ValidateToken(tokens.AccessToken)
+		_, err = differentSecretService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
 		assert.Error(t, err)
 	})
 }
@@ -150,14 +156,15 @@ func TestJWTClaims(t *testing.T) {
 		}
 
 		cache := NewMemoryCacheService()
-		jwtService := NewJWTService(cfg, cache)
+		jwtService, err := NewJWTService(cfg, cache, nil)
+		require.NoError(t, err)
 
 		testUserID := uint(1)
 
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 
-		claims, err := jwtService.ValidateToken(tokens.AccessToken)
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
 		require.NoError(t, err)
 
 		assert.Equal(t, "spy-cats-api", claims.Issuer)
@@ -176,16 +183,17 @@ func TestJWTClaims(t *testing.T) {
 		}
 
 		cache := NewMemoryCacheService()
-		jwtService := NewJWTService(cfg, cache)
+		jwtService, err := NewJWTService(cfg, cache, nil)
+		require.NoError(t, err)
 
 		testUserID := uint(1)
 
 		beforeGeneration := time.Now()
-		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole)
+		tokens, err := jwtService.GenerateTokenPair(testUserID, testUsername, testRole, DeviceInfo{})
 		require.NoError(t, err)
 		afterGeneration := time.Now()
 
-		claims, err := jwtService.ValidateToken(tokens.AccessToken)
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
 		require.NoError(t, err)
 
 		// IssuedAt should be between before and after generation (with some tolerance)
@@ -214,3 +222,102 @@ func TestJWTClaims(t *testing.T) {
 			timeDiff, expectedExpiry, claims.ExpiresAt.Time)
 	})
 }
+
+func TestJWTTokenTypes(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWT{
+			Secret:          "test-secret-key-for-jwt-testing",
+			RefreshSecret:   "test-refresh-secret-key",
+			AccessTokenTTL:  900,
+			RefreshTokenTTL: 604800,
+			AccessAudience:  "spy-cats-api",
+			RefreshAudience: "spy-cats-api/refresh",
+		},
+		App: config.App{
+			Name: "spy-cats-api",
+		},
+	}
+
+	cache := NewMemoryCacheService()
+	defer cache.Close()
+	jwtService, err := NewJWTService(cfg, cache, nil)
+	require.NoError(t, err)
+
+	tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+	require.NoError(t, err)
+
+	t.Run("tokens should carry their type and audience", func(t *testing.T) {
+		access, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+		assert.Equal(t, TokenTypeAccess, access.Type)
+		assert.Equal(t, jwt.ClaimStrings{"spy-cats-api"}, access.Audience)
+
+		refresh, err := jwtService.ValidateToken(tokens.RefreshToken, TokenTypeRefresh)
+		require.NoError(t, err)
+		assert.Equal(t, TokenTypeRefresh, refresh.Type)
+		assert.Equal(t, jwt.ClaimStrings{"spy-cats-api/refresh"}, refresh.Audience)
+	})
+
+	t.Run("a refresh token should not be accepted as an access token", func(t *testing.T) {
+		_, err := jwtService.ValidateToken(tokens.RefreshToken, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("an access token should not be accepted as a refresh token", func(t *testing.T) {
+		_, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeRefresh)
+		assert.Error(t, err)
+
+		_, err = jwtService.RefreshToken(tokens.AccessToken, DeviceInfo{})
+		assert.Error(t, err)
+	})
+
+	t.Run("refresh tokens should be signed with the refresh secret", func(t *testing.T) {
+		_, err := jwt.Parse(tokens.RefreshToken, func(*jwt.Token) (interface{}, error) {
+			return []byte(cfg.JWT.RefreshSecret), nil
+		})
+		assert.NoError(t, err)
+
+		_, err = jwt.Parse(tokens.RefreshToken, func(*jwt.Token) (interface{}, error) {
+			return []byte(cfg.JWT.Secret), nil
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("the type claim should be enforced even with a shared key and audience", func(t *testing.T) {
+		shared := *cfg
+		shared.JWT.RefreshSecret = ""
+		shared.JWT.RefreshAudience = shared.JWT.AccessAudience
+		sharedService, err := NewJWTService(&shared, cache, nil)
+		require.NoError(t, err)
+
+		sharedTokens, err := sharedService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+		require.NoError(t, err)
+
+		_, err = sharedService.ValidateToken(sharedTokens.RefreshToken, TokenTypeAccess)
+		assert.ErrorContains(t, err, "invalid token type")
+	})
+
+	t.Run("tokens without a type claim should be rejected", func(t *testing.T) {
+		legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": 1,
+			"aud":     "spy-cats-api",
+			"iss":     "spy-cats-api",
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		token, err := legacy.SignedString([]byte(cfg.JWT.Secret))
+		require.NoError(t, err)
+
+		_, err = jwtService.ValidateToken(token, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("tokens for another audience should be rejected", func(t *testing.T) {
+		other := *cfg
+		other.JWT.AccessAudience = "another-service"
+		otherService, err := NewJWTService(&other, cache, nil)
+		require.NoError(t, err)
+
+		_, err = otherService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		assert.Error(t, err)
+	})
+}
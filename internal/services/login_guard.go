@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"DevelopsToday/config"
+)
+
+// ErrLoginLocked is returned while logins are delayed or locked out after
+// too many failed attempts
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError tells how long the client has to wait before trying again
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked, e.RetryAfter)
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// Scopes of the failed login counters
+const (
+	LoginScopeUser = "user"
+	LoginScopeIP   = "ip"
+)
+
+// Reasons for unlocking logins
+const (
+	LoginUnlockExpired = "expired"
+	LoginUnlockAdmin   = "admin"
+)
+
+const EventLoginLocked = "auth.login_locked"
+
+// LoginLocked is raised when a username or client IP is locked out after
+// too many failed logins
+type LoginLocked struct {
+	LockedAt time.Time
+	Until    time.Time
+	Scope    string
+	// Subject is the username or the IP
+	Subject  string
+	Failures int
+}
+
+func (LoginLocked) EventName() string {
+	return EventLoginLocked
+}
+
+const EventLoginUnlocked = "auth.login_unlocked"
+
+// LoginUnlocked is raised when a lockout is lifted by an admin, or noticed
+// to have expired on the next login attempt
+type LoginUnlocked struct {
+	UnlockedAt time.Time
+	Scope      string
+	Subject    string
+	Reason     string
+}
+
+func (LoginUnlocked) EventName() string {
+	return EventLoginUnlocked
+}
+
+// loginBlock is a backoff or lockout of one username or IP
+type loginBlock struct {
+	Until time.Time `json:"until"`
+}
+
+// loginPolicy is the number of failed attempts of a scope that start the
+// backoff and that lock logins out
+type loginPolicy struct {
+	free    int
+	lockout int
+}
+
+// LoginGuard protects logins against brute force. Failed attempts are
+// counted per username and per client IP. Once a counter goes past the free
+// attempts every further attempt has to wait exponentially longer, and at
+// the lockout threshold logins are locked out for a fixed time.
+//
+// Failures are counted with CacheCounter when the cache implements it, so
+// concurrent attempts on any instance are all counted. Other caches fall
+// back to counting in process memory, like RateLimiter. The backoff and the
+// lockout are derived from the count and stored as separate keys, so a
+// backoff never replaces a lockout.
+type LoginGuard struct {
+	cache    CacheService
+	counters loginCounters
+	events   EventPublisher
+	cfg      config.Login
+	now      func() time.Time
+}
+
+// loginCounters are failure counters that can be reset
+type loginCounters interface {
+	CacheCounter
+	Delete(ctx context.Context, key string) error
+}
+
+// NewLoginGuard creates the guard. Zero settings fall back to the defaults,
+// events receives lock and unlock events and may be nil.
+func NewLoginGuard(cfg config.Login, cache CacheService, events EventPublisher) *LoginGuard {
+	if events == nil {
+		events = noopPublisher{}
+	}
+	if cfg.FreeAttempts <= 0 {
+		cfg.FreeAttempts = 3
+	}
+	if cfg.IPFreeAttempts <= 0 {
+		cfg.IPFreeAttempts = 20
+	}
+	if cfg.UserLockoutAttempts <= 0 {
+		cfg.UserLockoutAttempts = 10
+	}
+	if cfg.IPLockoutAttempts <= 0 {
+		cfg.IPLockoutAttempts = 100
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = time.Second
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = 5 * time.Minute
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+
+	guard := &LoginGuard{cache: cache, events: events, cfg: cfg, now: time.Now}
+	if counters, ok := cache.(loginCounters); ok {
+		guard.counters = counters
+	} else {
+		guard.counters = newLocalCounters()
+	}
+	return guard
+}
+
+// normalizeLoginName makes counters independent of case and whitespace
+func normalizeLoginName(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// loginAttemptsKey is the failure counter of a subject. It is hashed, login
+// names are not length limited.
+func loginAttemptsKey(scope, subject string) string {
+	return fmt.Sprintf("login_attempts:%s:%s", scope, hashToken(subject))
+}
+
+// loginBackoffKey and loginLockoutKey hold the loginBlock of a subject
+func loginBackoffKey(scope, subject string) string {
+	return loginAttemptsKey(scope, subject) + ":backoff"
+}
+
+func loginLockoutKey(scope, subject string) string {
+	return loginAttemptsKey(scope, subject) + ":lockout"
+}
+
+func (g *LoginGuard) policy(scope string) loginPolicy {
+	if scope == LoginScopeIP {
+		return loginPolicy{free: g.cfg.IPFreeAttempts, lockout: g.cfg.IPLockoutAttempts}
+	}
+	return loginPolicy{free: g.cfg.FreeAttempts, lockout: g.cfg.UserLockoutAttempts}
+}
+
+// backoff returns the delay after the given number of failures: nothing for
+// the free attempts, then BackoffBase doubling up to BackoffMax
+func (g *LoginGuard) backoff(p loginPolicy, failures int) time.Duration {
+	over := failures - p.free
+	if over <= 0 {
+		return 0
+	}
+
+	delay := g.cfg.BackoffBase
+	for i := 1; i < over && delay < g.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.BackoffMax {
+		return g.cfg.BackoffMax
+	}
+	return delay
+}
+
+// Check returns a *LoginLockedError when the username or the IP has to wait
+// before the next attempt. It fails open when the cache is unavailable, like
+// the token blacklist.
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	now := g.now()
+	var retryAfter time.Duration
+	for _, s := range g.subjects(username, ip) {
+		until, err := g.blockedUntil(ctx, s.scope, s.subject, now)
+		if err != nil {
+			continue
+		}
+		if wait := until.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Failure counts a failed login for the username and the IP
+func (g *LoginGuard) Failure(ctx context.Context, username, ip string) error {
+	now := g.now()
+	for _, s := range g.subjects(username, ip) {
+		failures, err := g.counters.Increment(ctx, loginAttemptsKey(s.scope, s.subject), g.cfg.Window)
+		if err != nil {
+			return fmt.Errorf("failed to count login attempt: %w", err)
+		}
+
+		p := g.policy(s.scope)
+		if failures >= int64(p.lockout) {
+			until := now.Add(g.cfg.LockoutDuration)
+			// Keep the lockout for the window after it ends, so its expiry
+			// is still there to be reported
+			if err := g.block(ctx, loginLockoutKey(s.scope, s.subject), until, g.cfg.LockoutDuration+g.cfg.Window); err != nil {
+				return err
+			}
+			// The counter is atomic, so exactly one failure reaches the threshold
+			if failures == int64(p.lockout) {
+				g.events.Publish(ctx, LoginLocked{
+					LockedAt: now,
+					Until:    until,
+					Scope:    s.scope,
+					Subject:  s.subject,
+					Failures: int(failures),
+				})
+			}
+			continue
+		}
+
+		if delay := g.backoff(p, int(failures)); delay > 0 {
+			if err := g.block(ctx, loginBackoffKey(s.scope, s.subject), now.Add(delay), delay); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Success resets the counter of the username. The IP counter is left to
+// expire, otherwise one valid account would let an attacker keep guessing
+// the passwords of others.
+func (g *LoginGuard) Success(ctx context.Context, username string) error {
+	return g.reset(ctx, LoginScopeUser, normalizeLoginName(username))
+}
+
+// Unlock lifts the lockout and backoff of a username and, unless ip is
+// empty, of a client IP
+func (g *LoginGuard) Unlock(ctx context.Context, username, ip string) error {
+	for _, s := range g.subjects(username, ip) {
+		if err := g.reset(ctx, s.scope, s.subject); err != nil {
+			return fmt.Errorf("failed to unlock login: %w", err)
+		}
+
+		g.events.Publish(ctx, LoginUnlocked{
+			UnlockedAt: g.now(),
+			Scope:      s.scope,
+			Subject:    s.subject,
+			Reason:     LoginUnlockAdmin,
+		})
+	}
+	return nil
+}
+
+type loginSubject struct {
+	scope   string
+	subject string
+}
+
+func (g *LoginGuard) subjects(username, ip string) []loginSubject {
+	subjects := []loginSubject{{scope: LoginScopeUser, subject: normalizeLoginName(username)}}
+	if ip != "" {
+		subjects = append(subjects, loginSubject{scope: LoginScopeIP, subject: ip})
+	}
+	return subjects
+}
+
+// blockedUntil returns the end of the later of the subject's backoff and
+// lockout. An expired lockout starts counting over from zero and is
+// reported as unlocked.
+func (g *LoginGuard) blockedUntil(ctx context.Context, scope, subject string, now time.Time) (time.Time, error) {
+	lockout, err := g.loadBlock(ctx, loginLockoutKey(scope, subject))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !lockout.Until.IsZero() && !now.Before(lockout.Until) {
+		if err := g.reset(ctx, scope, subject); err != nil {
+			return time.Time{}, err
+		}
+		g.events.Publish(ctx, LoginUnlocked{
+			UnlockedAt: now,
+			Scope:      scope,
+			Subject:    subject,
+			Reason:     LoginUnlockExpired,
+		})
+		return time.Time{}, nil
+	}
+
+	backoff, err := g.loadBlock(ctx, loginBackoffKey(scope, subject))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if backoff.Until.After(lockout.Until) {
+		return backoff.Until, nil
+	}
+	return lockout.Until, nil
+}
+
+func (g *LoginGuard) loadBlock(ctx context.Context, key string) (loginBlock, error) {
+	var block loginBlock
+	err := g.cache.GetJSON(ctx, key, &block)
+	if errors.Is(err, ErrCacheMiss) {
+		return loginBlock{}, nil
+	}
+	if err != nil {
+		return loginBlock{}, fmt.Errorf("failed to load login attempts: %w", err)
+	}
+	return block, nil
+}
+
+// block stores a backoff or lockout ending at until
+func (g *LoginGuard) block(ctx context.Context, key string, until time.Time, ttl time.Duration) error {
+	if err := g.cache.SetJSON(ctx, key, loginBlock{Until: until}, ttl); err != nil {
+		return fmt.Errorf("failed to store login attempts: %w", err)
+	}
+	return nil
+}
+
+// reset drops the counter, backoff and lockout of a subject
+func (g *LoginGuard) reset(ctx context.Context, scope, subject string) error {
+	if err := g.counters.Delete(ctx, loginAttemptsKey(scope, subject)); err != nil {
+		return err
+	}
+	if err := g.cache.Delete(ctx, loginBackoffKey(scope, subject)); err != nil {
+		return err
+	}
+	return g.cache.Delete(ctx, loginLockoutKey(scope, subject))
+}
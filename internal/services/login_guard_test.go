@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	events []Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event Event) {
+	p.events = append(p.events, event)
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	const ip = "203.0.113.7"
+
+	setup := func(t *testing.T) (*LoginGuard, *recordingPublisher, *time.Time) {
+		cache := NewMemoryCacheService()
+		t.Cleanup(func() { cache.Close() })
+
+		events := &recordingPublisher{}
+		guard := NewLoginGuard(config.Login{
+			FreeAttempts:        2,
+			IPFreeAttempts:      5,
+			BackoffBase:         time.Second,
+			BackoffMax:          4 * time.Second,
+			UserLockoutAttempts: 6,
+			IPLockoutAttempts:   20,
+			LockoutDuration:     time.Minute,
+			Window:              time.Hour,
+		}, cache, events)
+
+		now := time.Now()
+		guard.now = func() time.Time { return now }
+		return guard, events, &now
+	}
+
+	retryAfter := func(t *testing.T, err error) time.Duration {
+		t.Helper()
+		var locked *LoginLockedError
+		require.True(t, errors.As(err, &locked), "expected a lockout, got %v", err)
+		return locked.RetryAfter
+	}
+
+	t.Run("free attempts should not be delayed", func(t *testing.T) {
+		guard, _, _ := setup(t)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, guard.Check(ctx, "whiskers", ip))
+			require.NoError(t, guard.Failure(ctx, "whiskers", ip))
+		}
+		assert.NoError(t, guard.Check(ctx, "whiskers", ip))
+	})
+
+	t.Run("the backoff should double up to the maximum", func(t *testing.T) {
+		guard, _, now := setup(t)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, guard.Failure(ctx, "whiskers", ip))
+		}
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			require.NoError(t, guard.Failure(ctx, "whiskers", ip))
+			assert.Equal(t, want, retryAfter(t, guard.Check(ctx, "whiskers", ip)))
+
+			*now = now.Add(want)
+			assert.NoError(t, guard.Check(ctx, "whiskers", ip))
+		}
+	})
+
+	t.Run("usernames should be counted regardless of case", func(t *testing.T) {
+		guard, _, _ := setup(t)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, guard.Failure(ctx, "Whiskers", ""))
+		}
+		assert.ErrorIs(t, guard.Check(ctx, " whiskers", ""), ErrLoginLocked)
+		assert.NoError(t, guard.Check(ctx, "mittens", ""))
+	})
+
+	t.Run("the IP should be delayed across usernames", func(t *testing.T) {
+		guard, _, _ := setup(t)
+
+		for _, username := range []string{"a", "b", "c", "d", "e", "f"} {
+			require.NoError(t, guard.Failure(ctx, username, ip))
+		}
+		assert.ErrorIs(t, guard.Check(ctx, "mittens", ip), ErrLoginLocked)
+		assert.NoError(t, guard.Check(ctx, "mittens", "198.51.100.1"))
+	})
+
+	t.Run("a lockout should be reported and expire", func(t *testing.T) {
+		guard, events, now := setup(t)
+
+		for i := 0; i < 6; i++ {
+			require.NoError(t, guard.Failure(ctx, "whiskers", ip))
+		}
+		assert.Equal(t, time.Minute, retryAfter(t, guard.Check(ctx, "whiskers", ip)))
+
+		require.Len(t, events.events, 1)
+		locked, ok := events.events[0].(LoginLocked)
+		require.True(t, ok)
+		assert.Equal(t, LoginScopeUser, locked.Scope)
+		assert.Equal(t, "whiskers", locked.Subject)
+		assert.Equal(t, 6, locked.Failures)
+
+		*now = now.Add(time.Minute)
+		require.NoError(t, guard.Check(ctx, "whiskers", ip))
+		require.Len(t, events.events, 2)
+		assert.Equal(t, LoginUnlocked{UnlockedAt: *now, Scope: LoginScopeUser, Subject: "whiskers", Reason: LoginUnlockExpired}, events.events[1])
+
+		// Counting starts over for the username
+		require.NoError(t, guard.Failure(ctx, "whiskers", ""))
+		assert.NoError(t, guard.Check(ctx, "whiskers", ""))
+	})
+
+	t.Run("a successful login should reset the username but not the IP", func(t *testing.T) {
+		guard, _, _ := setup(t)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, guard.Failure(ctx, "whiskers", ip))
+		}
+		require.NoError(t, guard.Success(ctx, "whiskers"))
+		assert.NoError(t, guard.Check(ctx, "whiskers", ""))
+
+		require.NoError(t, guard.Failure(ctx, "mittens", ip))
+		assert.ErrorIs(t, guard.Check(ctx, "mittens", ip), ErrLoginLocked)
+	})
+
+	t.Run("Unlock should lift the lockout of a username", func(t *testing.T) {
+		guard, events, _ := setup(t)
+
+		for i := 0; i < 6; i++ {
+			require.NoError(t, guard.Failure(ctx, "whiskers", ""))
+		}
+		require.ErrorIs(t, guard.Check(ctx, "whiskers", ""), ErrLoginLocked)
+
+		require.NoError(t, guard.Unlock(ctx, "Whiskers", ""))
+		assert.NoError(t, guard.Check(ctx, "whiskers", ""))
+
+		unlocked, ok := events.events[len(events.events)-1].(LoginUnlocked)
+		require.True(t, ok)
+		assert.Equal(t, LoginUnlockAdmin, unlocked.Reason)
+	})
+
+	t.Run("Unlock with an IP should lift the lockout of the client IP too", func(t *testing.T) {
+		guard, events, _ := setup(t)
+
+		for i := 0; i < 20; i++ {
+			require.NoError(t, guard.Failure(ctx, fmt.Sprintf("agent%d", i), ip))
+		}
+		require.ErrorIs(t, guard.Check(ctx, "whiskers", ip), ErrLoginLocked)
+
+		require.NoError(t, guard.Unlock(ctx, "whiskers", ip))
+		assert.NoError(t, guard.Check(ctx, "mittens", ip))
+
+		unlocked, ok := events.events[len(events.events)-1].(LoginUnlocked)
+		require.True(t, ok)
+		assert.Equal(t, LoginUnlocked{UnlockedAt: unlocked.UnlockedAt, Scope: LoginScopeIP, Subject: ip, Reason: LoginUnlockAdmin}, unlocked)
+	})
+
+	t.Run("concurrent failures on several instances should all be counted", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := NewRedisCacheService(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		t.Cleanup(func() { cache.Close() })
+
+		var events lockedPublisher
+		cfg := config.Login{FreeAttempts: 100, UserLockoutAttempts: 20, LockoutDuration: time.Minute}
+		guards := []*LoginGuard{NewLoginGuard(cfg, cache, &events), NewLoginGuard(cfg, cache, &events)}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func(guard *LoginGuard) {
+				defer wg.Done()
+				assert.NoError(t, guard.Failure(ctx, "whiskers", ""))
+			}(guards[i%2])
+		}
+		wg.Wait()
+
+		failures, err := cache.(CacheCounter).Counter(ctx, loginAttemptsKey(LoginScopeUser, "whiskers"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), failures)
+		assert.ErrorIs(t, guards[0].Check(ctx, "whiskers", ""), ErrLoginLocked)
+		assert.Equal(t, 1, events.count(), "the lockout must be reported once")
+	})
+}
+
+// lockedPublisher counts events from concurrent publishers
+type lockedPublisher struct {
+	events int
+	mutex  sync.Mutex
+}
+
+func (p *lockedPublisher) Publish(_ context.Context, _ Event) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events++
+}
+
+func (p *lockedPublisher) count() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.events
+}
@@ -2,68 +2,267 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// memcachedMaxKeyLength is the longest key memcached accepts
+	memcachedMaxKeyLength = 250
+	// memcachedMaxRelativeTTL is the longest expiration memcached treats as
+	// relative; larger values are interpreted as a unix timestamp
+	memcachedMaxRelativeTTL = 30 * 24 * time.Hour
 )
 
 // MemcachedCacheService implements CacheService using Memcached
-// This is a placeholder implementation for future use
 type MemcachedCacheService struct {
-	// Add memcached client here when needed
-	// client *memcache.Client
+	client *memcache.Client
 }
 
 // NewMemcachedCacheService creates a new Memcached cache service
-func NewMemcachedCacheService(servers []string) CacheService {
+func NewMemcachedCacheService(client *memcache.Client) CacheService {
 	return &MemcachedCacheService{
-		// Initialize memcached client here
+		client: client,
 	}
 }
 
 // Set stores a key-value pair with optional TTL
 func (m *MemcachedCacheService) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	// TODO: Implement memcached set operation
-	return fmt.Errorf("memcached implementation not yet available")
+	data, err := memcachedValue(value)
+	if err != nil {
+		return err
+	}
+
+	return m.client.Set(&memcache.Item{
+		Key:        memcachedKey(key),
+		Value:      data,
+		Expiration: memcachedExpiration(ttl),
+	})
 }
 
 // Get retrieves a value by key
 func (m *MemcachedCacheService) Get(ctx context.Context, key string) (string, error) {
-	// TODO: Implement memcached get operation
-	return "", fmt.Errorf("memcached implementation not yet available")
+	item, err := m.client.Get(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return "", ErrCacheMiss
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(item.Value), nil
 }
 
 // Delete removes a key from cache
 func (m *MemcachedCacheService) Delete(ctx context.Context, key string) error {
-	// TODO: Implement memcached delete operation
-	return fmt.Errorf("memcached implementation not yet available")
+	err := m.client.Delete(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil
+	}
+	return err
 }
 
 // Exists checks if a key exists in cache
 func (m *MemcachedCacheService) Exists(ctx context.Context, key string) (bool, error) {
-	// TODO: Implement memcached exists operation
-	return false, fmt.Errorf("memcached implementation not yet available")
+	_, err := m.client.Get(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // SetJSON stores a JSON-serializable object
 func (m *MemcachedCacheService) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	// TODO: Implement memcached setJSON operation
-	return fmt.Errorf("memcached implementation not yet available")
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return m.Set(ctx, key, data, ttl)
 }
 
 // GetJSON retrieves and unmarshals a JSON object
 func (m *MemcachedCacheService) GetJSON(ctx context.Context, key string, dest interface{}) error {
-	// TODO: Implement memcached getJSON operation
-	return fmt.Errorf("memcached implementation not yet available")
+	data, err := m.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal([]byte(data), dest)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return nil
+}
+
+// CompareAndSwap replaces the value at key when it equals old, using
+// memcached's check-and-set so that a concurrent write makes it fail
+func (m *MemcachedCacheService) CompareAndSwap(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	item, err := m.client.Get(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if string(item.Value) != old {
+		return false, nil
+	}
+
+	item.Value = []byte(value)
+	item.Expiration = memcachedExpiration(ttl)
+	err = m.client.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) ||
+		errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetIfAbsent stores value when key does not exist, with memcached's add
+func (m *MemcachedCacheService) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	err := m.client.Add(&memcache.Item{
+		Key:        memcachedKey(key),
+		Value:      []byte(value),
+		Expiration: memcachedExpiration(ttl),
+	})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetDelete returns and removes the value at key. Memcached cannot delete
+// conditionally, so the item is instead replaced by one that expires at
+// once, with check-and-set: of concurrent callers only one succeeds.
+func (m *MemcachedCacheService) GetDelete(ctx context.Context, key string) (string, error) {
+	item, err := m.client.Get(memcachedKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return "", ErrCacheMiss
+	}
+	if err != nil {
+		return "", err
+	}
+
+	value := string(item.Value)
+	item.Value = nil
+	item.Expiration = -1
+	err = m.client.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) ||
+		errors.Is(err, memcache.ErrCacheMiss) {
+		return "", ErrCacheMiss
+	}
+	if err != nil {
+		return "", err
+	}
+	return value, nil
 }
 
 // Ping checks if the cache service is available
 func (m *MemcachedCacheService) Ping(ctx context.Context) error {
-	// TODO: Implement memcached ping operation
-	return fmt.Errorf("memcached implementation not yet available")
+	return m.client.Ping()
 }
 
 // Close closes the cache connection
 func (m *MemcachedCacheService) Close() error {
-	// TODO: Implement memcached close operation
-	return nil
+	return m.client.Close()
+}
+
+// memcachedKey returns key unchanged when memcached accepts it. Longer keys
+// or keys with whitespace and control characters (e.g. blacklisted JWTs)
+// are replaced by their SHA-256 digest.
+func memcachedKey(key string) string {
+	if len(key) > 0 && len(key) <= memcachedMaxKeyLength {
+		legal := true
+		for i := 0; i < len(key); i++ {
+			if key[i] <= ' ' || key[i] == 0x7f {
+				legal = false
+				break
+			}
+		}
+		if legal {
+			return key
+		}
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// memcachedExpiration converts ttl into memcached's expiration format.
+// ttl <= 0 means no expiration. Memcached works in whole seconds, so shorter
+// TTLs are rounded up to one second.
+func memcachedExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcachedMaxRelativeTTL {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+
+	seconds := int32(ttl / time.Second)
+	if ttl%time.Second != 0 {
+		seconds++
+	}
+	return seconds
+}
+
+// memcachedValue converts value into the bytes stored in memcached, using the
+// same textual form Redis uses for scalars and JSON for everything else
+func memcachedValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return []byte{}, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case bool:
+		if v {
+			return []byte("1"), nil
+		}
+		return []byte("0"), nil
+	case int:
+		return []byte(strconv.FormatInt(int64(v), 10)), nil
+	case int32:
+		return []byte(strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return []byte(strconv.FormatInt(v, 10)), nil
+	case uint:
+		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+	case uint32:
+		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+	case uint64:
+		return []byte(strconv.FormatUint(v, 10)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case time.Time:
+		return []byte(v.Format(time.RFC3339Nano)), nil
+	case time.Duration:
+		return []byte(strconv.FormatInt(int64(v), 10)), nil
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value: %w", err)
+		}
+		return data, nil
+	}
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemcachedCacheService(t *testing.T) {
+	server := runMemcachedStandIn(t)
+	cache := NewMemcachedCacheService(memcache.New(server.Addr()))
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	t.Run("Set should accept keys memcached cannot store as is", func(t *testing.T) {
+		longKey := "blacklist:" + strings.Repeat("x", 300)
+		spacedKey := "key with spaces"
+
+		require.NoError(t, cache.Set(ctx, longKey, "long", time.Minute))
+		require.NoError(t, cache.Set(ctx, spacedKey, "spaced", time.Minute))
+
+		result, err := cache.Get(ctx, longKey)
+		require.NoError(t, err)
+		assert.Equal(t, "long", result)
+
+		result, err = cache.Get(ctx, spacedKey)
+		require.NoError(t, err)
+		assert.Equal(t, "spaced", result)
+	})
+
+	t.Run("Ping should fail when the server is down", func(t *testing.T) {
+		down := NewMemcachedCacheService(memcache.New("127.0.0.1:1"))
+		assert.Error(t, down.Ping(ctx))
+	})
+}
+
+func TestMemcachedExpiration(t *testing.T) {
+	assert.Equal(t, int32(0), memcachedExpiration(0))
+	assert.Equal(t, int32(0), memcachedExpiration(-time.Second))
+	assert.Equal(t, int32(1), memcachedExpiration(100*time.Millisecond))
+	assert.Equal(t, int32(60), memcachedExpiration(time.Minute))
+
+	// Beyond 30 days memcached expects an absolute unix timestamp
+	week := 7 * 24 * time.Hour
+	expiration := memcachedExpiration(5 * week)
+	assert.InDelta(t, time.Now().Add(5*week).Unix(), int64(expiration), 2)
+}
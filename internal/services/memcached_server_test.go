@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memcachedStandIn is a tiny in-process server speaking the memcached text
+// protocol. It covers the commands used by gomemcache (get/gets, set, add,
+// replace, cas, delete, touch, version, flush_all), so MemcachedCacheService
+// can be tested without a real memcached, the same way miniredis is used for
+// Redis.
+type memcachedStandIn struct {
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	items    map[string]standInItem
+	offset   time.Duration
+	casID    uint64 // the last check-and-set ID handed out
+	mutex    sync.Mutex
+	wg       sync.WaitGroup
+}
+
+type standInItem struct {
+	value     []byte
+	flags     uint32
+	expiresAt time.Time
+	casID     uint64
+}
+
+func runMemcachedStandIn(t *testing.T) *memcachedStandIn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start memcached stand-in: %v", err)
+	}
+
+	s := &memcachedStandIn{
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+		items:    make(map[string]standInItem),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Addr returns the host:port the server listens on
+func (s *memcachedStandIn) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// FastForward moves the server clock forward to expire items
+func (s *memcachedStandIn) FastForward(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.offset += d
+}
+
+// Close stops the server and drops open client connections
+func (s *memcachedStandIn) Close() {
+	_ = s.listener.Close()
+
+	s.mutex.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *memcachedStandIn) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+func (s *memcachedStandIn) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mutex.Lock()
+			delete(s.conns, conn)
+			s.mutex.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (s *memcachedStandIn) handle(conn net.Conn) {
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var reply string
+		switch fields[0] {
+		case "get", "gets":
+			reply = s.get(fields[1:])
+		case "set", "add", "replace", "cas":
+			reply, err = s.store(rw.Reader, fields)
+		case "delete":
+			reply = s.delete(fields[1:])
+		case "touch":
+			reply = s.touch(fields[1:])
+		case "version":
+			reply = "VERSION stand-in\r\n"
+		case "flush_all":
+			s.mutex.Lock()
+			s.items = make(map[string]standInItem)
+			s.mutex.Unlock()
+			reply = "OK\r\n"
+		default:
+			reply = "ERROR\r\n"
+		}
+		if err != nil {
+			return
+		}
+
+		if _, err := rw.WriteString(reply); err != nil {
+			return
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// lookup returns a live item, dropping it when expired. Caller holds the mutex.
+func (s *memcachedStandIn) lookup(key string) (standInItem, bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return standInItem{}, false
+	}
+	if !item.expiresAt.IsZero() && !s.now().Before(item.expiresAt) {
+		delete(s.items, key)
+		return standInItem{}, false
+	}
+	return item, true
+}
+
+func (s *memcachedStandIn) get(keys []string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var b strings.Builder
+	for _, key := range keys {
+		item, ok := s.lookup(key)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "VALUE %s %d %d %d\r\n%s\r\n", key, item.flags, len(item.value), item.casID, item.value)
+	}
+	b.WriteString("END\r\n")
+	return b.String()
+}
+
+// store handles "<cmd> <key> <flags> <exptime> <bytes> [<cas unique>]"
+// followed by a data block
+func (s *memcachedStandIn) store(r *bufio.Reader, fields []string) (string, error) {
+	if len(fields) < 5 {
+		return "CLIENT_ERROR bad command line format\r\n", nil
+	}
+
+	flags, _ := strconv.ParseUint(fields[2], 10, 32)
+	exptime, _ := strconv.ParseInt(fields[3], 10, 64)
+	size, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return "CLIENT_ERROR bad data chunk\r\n", nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, exists := s.lookup(fields[1])
+	if (fields[0] == "add" && exists) || (fields[0] == "replace" && !exists) {
+		return "NOT_STORED\r\n", nil
+	}
+	if fields[0] == "cas" {
+		if len(fields) < 6 {
+			return "CLIENT_ERROR bad command line format\r\n", nil
+		}
+		if !exists {
+			return "NOT_FOUND\r\n", nil
+		}
+		if casID, _ := strconv.ParseUint(fields[5], 10, 64); casID != current.casID {
+			return "EXISTS\r\n", nil
+		}
+	}
+
+	s.casID++
+	s.items[fields[1]] = standInItem{
+		value:     data[:size],
+		flags:     uint32(flags),
+		expiresAt: s.expiresAt(exptime),
+		casID:     s.casID,
+	}
+	return "STORED\r\n", nil
+}
+
+func (s *memcachedStandIn) delete(args []string) string {
+	if len(args) < 1 {
+		return "ERROR\r\n"
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.lookup(args[0]); !ok {
+		return "NOT_FOUND\r\n"
+	}
+	delete(s.items, args[0])
+	return "DELETED\r\n"
+}
+
+func (s *memcachedStandIn) touch(args []string) string {
+	if len(args) < 2 {
+		return "ERROR\r\n"
+	}
+	exptime, _ := strconv.ParseInt(args[1], 10, 64)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, ok := s.lookup(args[0])
+	if !ok {
+		return "NOT_FOUND\r\n"
+	}
+	item.expiresAt = s.expiresAt(exptime)
+	s.items[args[0]] = item
+	return "TOUCHED\r\n"
+}
+
+// expiresAt applies memcached's exptime rules: 0 never expires, values up to
+// 30 days are relative seconds, larger values are unix timestamps.
+// Caller holds the mutex.
+func (s *memcachedStandIn) expiresAt(exptime int64) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return s.now()
+	case exptime <= int64(memcachedMaxRelativeTTL/time.Second):
+		return s.now().Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
@@ -1,14 +1,74 @@
 package services
 
 import (
+	"container/heap"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// MemoryCacheItem represents a cached item with expiration
+// ErrCacheValueTooLarge is returned by a bounded MemoryCacheService when a
+// single value does not fit into its memory limit
+var ErrCacheValueTooLarge = errors.New("cache: value exceeds memory limit")
+
+// ErrCachePinnedFull is returned by a bounded MemoryCacheService when a pinned
+// item does not fit into the limits of pinned items. Pinned items are never
+// evicted to make room, so callers fail instead of losing security state.
+var ErrCachePinnedFull = errors.New("cache: no room left for pinned items")
+
+// EvictionPolicy selects which item a bounded MemoryCacheService drops when full
+type EvictionPolicy string
+
+const (
+	// EvictionLRU drops the least recently used item
+	EvictionLRU EvictionPolicy = "lru"
+	// EvictionLFU drops the least frequently used item, the least recently
+	// used one among equally used items
+	EvictionLFU EvictionPolicy = "lfu"
+)
+
+const (
+	defaultMemoryCacheShards = 16
+	// memoryCacheItemOverhead approximates the bookkeeping cost of an item
+	memoryCacheItemOverhead = 64
+)
+
+// MemoryCacheOptions configures MemoryCacheService. The zero value is an
+// unbounded LRU cache.
+type MemoryCacheOptions struct {
+	// MaxEntries limits the number of items, 0 means no limit
+	MaxEntries int
+	// MaxBytes limits the approximate size of keys and values, 0 means no limit
+	MaxBytes int64
+	// Eviction is the eviction policy, LRU by default
+	Eviction EvictionPolicy
+	// Shards is the number of independently locked partitions, 16 by default
+	Shards int
+	// Pinned reports keys that must never be evicted. Pinned items still
+	// expire, but are not counted against MaxEntries and MaxBytes.
+	Pinned func(key string) bool
+	// MaxPinnedEntries and MaxPinnedBytes limit pinned items instead, 0 means
+	// no limit. Storing a pinned item beyond them fails with ErrCachePinnedFull.
+	MaxPinnedEntries int
+	MaxPinnedBytes   int64
+}
+
+// MemoryCacheStats is a snapshot of MemoryCacheService counters
+type MemoryCacheStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// MemoryCacheItem represents a cached item with expiration.
+// A zero ExpiresAt means the item never expires.
 type MemoryCacheItem struct {
 	Value     interface{}
 	ExpiresAt time.Time
@@ -16,29 +76,106 @@ type MemoryCacheItem struct {
 
 // IsExpired checks if the cache item has expired
 func (item *MemoryCacheItem) IsExpired() bool {
-	return time.Now().After(item.ExpiresAt)
+	return item.expiredAt(time.Now())
 }
 
-// MemoryCacheService implements CacheService using in-memory storage
+func (item *MemoryCacheItem) expiredAt(now time.Time) bool {
+	return !item.ExpiresAt.IsZero() && !now.Before(item.ExpiresAt)
+}
+
+// MemoryCacheService implements CacheService using in-memory storage.
+//
+// Keys are spread over shards, each with its own lock, limits and eviction
+// queue. Limits are split evenly between shards, so a shard may start
+// evicting slightly before the cache as a whole is full.
 type MemoryCacheService struct {
-	data   map[string]*MemoryCacheItem
-	mutex  sync.RWMutex
+	shards []*memoryCacheShard
 	ticker *time.Ticker
 	done   chan bool
+	// now is the clock used for expiration, replaced in tests
+	now func() time.Time
+	// pinned reports keys exempt from eviction
+	pinned func(key string) bool
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
 }
 
-// NewMemoryCacheService creates a new in-memory cache service
+// NewMemoryCacheService creates a new unbounded in-memory cache service
 func NewMemoryCacheService() CacheService {
+	cache, _ := NewMemoryCacheServiceWithOptions(MemoryCacheOptions{})
+	return cache
+}
+
+// NewMemoryCacheServiceWithOptions creates an in-memory cache service bounded
+// by opts
+func NewMemoryCacheServiceWithOptions(opts MemoryCacheOptions) (*MemoryCacheService, error) {
+	if opts.MaxEntries < 0 || opts.MaxBytes < 0 || opts.Shards < 0 ||
+		opts.MaxPinnedEntries < 0 || opts.MaxPinnedBytes < 0 {
+		return nil, fmt.Errorf("memory cache limits must not be negative")
+	}
+
+	var lfu bool
+	switch opts.Eviction {
+	case "", EvictionLRU:
+	case EvictionLFU:
+		lfu = true
+	default:
+		return nil, fmt.Errorf("unsupported eviction policy: %s", opts.Eviction)
+	}
+
+	shards := opts.Shards
+	if shards == 0 {
+		shards = defaultMemoryCacheShards
+	}
+	// Every shard must be able to hold at least one item
+	if opts.MaxEntries > 0 && shards > opts.MaxEntries {
+		shards = opts.MaxEntries
+	}
+	if opts.Pinned != nil && opts.MaxPinnedEntries > 0 && shards > opts.MaxPinnedEntries {
+		shards = opts.MaxPinnedEntries
+	}
+
 	cache := &MemoryCacheService{
-		data:   make(map[string]*MemoryCacheItem),
+		shards: make([]*memoryCacheShard, shards),
 		ticker: time.NewTicker(time.Minute), // Clean up expired items every minute
 		done:   make(chan bool),
+		now:    time.Now,
+		pinned: opts.Pinned,
+	}
+	for i := range cache.shards {
+		cache.shards[i] = &memoryCacheShard{
+			items:      make(map[string]*memoryCacheEntry),
+			queue:      evictionQueue{lfu: lfu},
+			maxEntries: opts.MaxEntries / shards,
+			maxBytes:   opts.MaxBytes / int64(shards),
+
+			maxPinnedEntries: opts.MaxPinnedEntries / shards,
+			maxPinnedBytes:   opts.MaxPinnedBytes / int64(shards),
+		}
 	}
 
 	// Start cleanup goroutine
 	go cache.cleanup()
 
-	return cache
+	return cache, nil
+}
+
+// shard returns the shard owning key
+func (m *MemoryCacheService) shard(key string) *memoryCacheShard {
+	return m.shards[fnv1a(key)%uint32(len(m.shards))]
+}
+
+// fnv1a hashes key with FNV-1a without allocating
+func fnv1a(key string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return hash
 }
 
 // cleanup removes expired items from the cache
@@ -46,13 +183,17 @@ func (m *MemoryCacheService) cleanup() {
 	for {
 		select {
 		case <-m.ticker.C:
-			m.mutex.Lock()
-			for key, item := range m.data {
-				if item.IsExpired() {
-					delete(m.data, key)
+			now := m.now()
+			for _, s := range m.shards {
+				s.mutex.Lock()
+				for _, entry := range s.items {
+					if entry.item.expiredAt(now) {
+						s.remove(entry)
+						m.expirations.Add(1)
+					}
 				}
+				s.mutex.Unlock()
 			}
-			m.mutex.Unlock()
 		case <-m.done:
 			return
 		}
@@ -61,44 +202,154 @@ func (m *MemoryCacheService) cleanup() {
 
 // Set stores a key-value pair with optional TTL
 func (m *MemoryCacheService) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return m.store(s, m.newEntry(key, value, ttl))
+}
+
+// CompareAndSwap replaces the value at key when it equals old
+func (m *MemoryCacheService) CompareAndSwap(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	expiresAt := time.Now().Add(ttl)
-	if ttl <= 0 {
-		expiresAt = time.Now().Add(time.Hour * 24 * 365) // 1 year for "no expiration"
+	current, exists := s.items[key]
+	if !exists || current.item.expiredAt(m.now()) {
+		return false, nil
+	}
+	if stored, err := memoryValueString(current.item.Value); err != nil || stored != old {
+		return false, err
+	}
+
+	if err := m.store(s, m.newEntry(key, value, ttl)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetIfAbsent stores value when key does not exist
+func (m *MemoryCacheService) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if current, exists := s.items[key]; exists && !current.item.expiredAt(m.now()) {
+		return false, nil
+	}
+	if err := m.store(s, m.newEntry(key, value, ttl)); err != nil {
+		return false, err
 	}
+	return true, nil
+}
+
+// GetDelete returns and removes the value at key
+func (m *MemoryCacheService) GetDelete(ctx context.Context, key string) (string, error) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	m.data[key] = &MemoryCacheItem{
-		Value:     value,
-		ExpiresAt: expiresAt,
+	entry, exists := s.items[key]
+	if !exists {
+		m.misses.Add(1)
+		return "", ErrCacheMiss
+	}
+	s.remove(entry)
+	if entry.item.expiredAt(m.now()) {
+		m.expirations.Add(1)
+		m.misses.Add(1)
+		return "", ErrCacheMiss
 	}
 
+	m.hits.Add(1)
+	return memoryValueString(entry.item.Value)
+}
+
+// newEntry prepares an item for Set
+func (m *MemoryCacheService) newEntry(key string, value interface{}, ttl time.Duration) *memoryCacheEntry {
+	var expiresAt time.Time // zero value: no expiration
+	if ttl > 0 {
+		expiresAt = m.now().Add(ttl)
+	}
+
+	entry := &memoryCacheEntry{
+		key:  key,
+		item: &MemoryCacheItem{Value: value, ExpiresAt: expiresAt},
+		size: memoryCacheSize(key, value),
+	}
+	entry.pinned = m.pinned != nil && m.pinned(entry.key)
+	return entry
+}
+
+// store replaces the item at entry.key, evicting others to make room. The
+// caller must hold the shard's mutex.
+func (m *MemoryCacheService) store(s *memoryCacheShard, entry *memoryCacheEntry) error {
+	if old, exists := s.items[entry.key]; exists {
+		s.remove(old)
+	}
+
+	if entry.pinned {
+		return m.storePinned(s, entry)
+	}
+
+	if s.maxBytes > 0 && entry.size > s.maxBytes {
+		return ErrCacheValueTooLarge
+	}
+
+	// Make room before inserting, so the new item is never the one evicted
+	now := m.now()
+	for s.queue.Len() > 0 && !s.fits(entry.size) {
+		victim := s.queue.entries[0]
+		s.remove(victim)
+		if victim.item.expiredAt(now) {
+			m.expirations.Add(1)
+		} else {
+			m.evictions.Add(1)
+		}
+	}
+
+	s.insert(entry)
+	return nil
+}
+
+// storePinned inserts a pinned item when it fits the limits of pinned items.
+// Only expired pinned items are dropped to make room. The caller must hold
+// the shard's mutex.
+func (m *MemoryCacheService) storePinned(s *memoryCacheShard, entry *memoryCacheEntry) error {
+	if s.maxPinnedBytes > 0 && entry.size > s.maxPinnedBytes {
+		return ErrCacheValueTooLarge
+	}
+
+	if !s.pinnedFits(entry.size) {
+		now := m.now()
+		// A full sweep only pays off once the earliest pinned item expired
+		if s.pinnedExpiry.IsZero() || now.Before(s.pinnedExpiry) {
+			return ErrCachePinnedFull
+		}
+		m.expirations.Add(uint64(s.sweepPinned(now)))
+		if !s.pinnedFits(entry.size) {
+			return ErrCachePinnedFull
+		}
+	}
+
+	s.insert(entry)
 	return nil
 }
 
 // Get retrieves a value by key
 func (m *MemoryCacheService) Get(ctx context.Context, key string) (string, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
-	item, exists := m.data[key]
+	item, exists := m.lookup(key)
 	if !exists {
-		return "", fmt.Errorf("key not found")
+		return "", ErrCacheMiss
 	}
 
-	if item.IsExpired() {
-		// Remove expired item
-		m.mutex.RUnlock()
-		m.mutex.Lock()
-		delete(m.data, key)
-		m.mutex.Unlock()
-		m.mutex.RLock()
-		return "", fmt.Errorf("key expired")
-	}
+	return memoryValueString(item.Value)
+}
 
-	// Convert value to string
-	switch v := item.Value.(type) {
+// memoryValueString converts a stored value to the string returned by Get
+func memoryValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
 	case string:
 		return v, nil
 	case []byte:
@@ -115,34 +366,45 @@ func (m *MemoryCacheService) Get(ctx context.Context, key string) (string, error
 
 // Delete removes a key from cache
 func (m *MemoryCacheService) Delete(ctx context.Context, key string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	delete(m.data, key)
+	if entry, exists := s.items[key]; exists {
+		s.remove(entry)
+	}
 	return nil
 }
 
 // Exists checks if a key exists in cache
 func (m *MemoryCacheService) Exists(ctx context.Context, key string) (bool, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	_, exists := m.lookup(key)
+	return exists, nil
+}
+
+// lookup returns a live item and records the access, removing the item when
+// it has expired
+func (m *MemoryCacheService) lookup(key string) (*MemoryCacheItem, bool) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	item, exists := m.data[key]
+	entry, exists := s.items[key]
 	if !exists {
-		return false, nil
+		m.misses.Add(1)
+		return nil, false
 	}
 
-	if item.IsExpired() {
-		// Remove expired item
-		m.mutex.RUnlock()
-		m.mutex.Lock()
-		delete(m.data, key)
-		m.mutex.Unlock()
-		m.mutex.RLock()
-		return false, nil
+	if entry.item.expiredAt(m.now()) {
+		s.remove(entry)
+		m.expirations.Add(1)
+		m.misses.Add(1)
+		return nil, false
 	}
 
-	return true, nil
+	s.touch(entry)
+	m.hits.Add(1)
+	return entry.item, true
 }
 
 // SetJSON stores a JSON-serializable object
@@ -180,25 +442,222 @@ func (m *MemoryCacheService) Close() error {
 	m.ticker.Stop()
 	close(m.done)
 
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
 	// Clear all data
-	m.data = make(map[string]*MemoryCacheItem)
+	m.Clear()
 
 	return nil
 }
 
 // Size returns the number of items in the cache
 func (m *MemoryCacheService) Size() int {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return len(m.data)
+	size := 0
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		size += len(s.items)
+		s.mutex.Unlock()
+	}
+	return size
 }
 
 // Clear removes all items from the cache
 func (m *MemoryCacheService) Clear() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	m.data = make(map[string]*MemoryCacheItem)
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		s.items = make(map[string]*memoryCacheEntry)
+		s.queue.entries = nil
+		s.bytes = 0
+		s.pinnedBytes = 0
+		s.pinnedEntries = 0
+		s.pinnedExpiry = time.Time{}
+		s.mutex.Unlock()
+	}
+}
+
+// Stats returns the cache counters and current usage
+func (m *MemoryCacheService) Stats() MemoryCacheStats {
+	stats := MemoryCacheStats{
+		Hits:        m.hits.Load(),
+		Misses:      m.misses.Load(),
+		Evictions:   m.evictions.Load(),
+		Expirations: m.expirations.Load(),
+	}
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		stats.Entries += len(s.items)
+		stats.Bytes += s.bytes + s.pinnedBytes
+		s.mutex.Unlock()
+	}
+	return stats
+}
+
+// memoryCacheSize approximates the memory held by an item
+func memoryCacheSize(key string, value interface{}) int64 {
+	size := int64(len(key) + memoryCacheItemOverhead)
+	switch v := value.(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			size += int64(len(data))
+		}
+	}
+	return size
+}
+
+// memoryCacheShard is one independently locked partition of MemoryCacheService
+type memoryCacheShard struct {
+	mutex sync.Mutex
+	items map[string]*memoryCacheEntry
+	queue evictionQueue
+	// bytes counts evictable items only, pinnedBytes and pinnedEntries the
+	// pinned ones
+	bytes         int64
+	pinnedBytes   int64
+	pinnedEntries int
+	// pinnedExpiry is no later than the earliest expiry of a pinned item,
+	// zero when none expires
+	pinnedExpiry time.Time
+	clock        uint64
+	maxEntries   int
+	maxBytes     int64
+
+	maxPinnedEntries int
+	maxPinnedBytes   int64
+}
+
+// fits reports whether an evictable item of size can be added without
+// exceeding limits. Pinned items are not counted.
+func (s *memoryCacheShard) fits(size int64) bool {
+	if s.maxEntries > 0 && s.queue.Len() >= s.maxEntries {
+		return false
+	}
+	if s.maxBytes > 0 && s.bytes+size > s.maxBytes {
+		return false
+	}
+	return true
+}
+
+// pinnedFits reports whether a pinned item of size can be added without
+// exceeding the limits of pinned items
+func (s *memoryCacheShard) pinnedFits(size int64) bool {
+	if s.maxPinnedEntries > 0 && s.pinnedEntries >= s.maxPinnedEntries {
+		return false
+	}
+	if s.maxPinnedBytes > 0 && s.pinnedBytes+size > s.maxPinnedBytes {
+		return false
+	}
+	return true
+}
+
+// sweepPinned drops expired pinned items and returns how many it dropped
+func (s *memoryCacheShard) sweepPinned(now time.Time) int {
+	dropped := 0
+	s.pinnedExpiry = time.Time{}
+	for _, entry := range s.items {
+		if !entry.pinned {
+			continue
+		}
+		if entry.item.expiredAt(now) {
+			s.remove(entry)
+			dropped++
+			continue
+		}
+		s.notePinnedExpiry(entry)
+	}
+	return dropped
+}
+
+func (s *memoryCacheShard) notePinnedExpiry(entry *memoryCacheEntry) {
+	expiresAt := entry.item.ExpiresAt
+	if !expiresAt.IsZero() && (s.pinnedExpiry.IsZero() || expiresAt.Before(s.pinnedExpiry)) {
+		s.pinnedExpiry = expiresAt
+	}
+}
+
+func (s *memoryCacheShard) insert(entry *memoryCacheEntry) {
+	s.clock++
+	entry.used = s.clock
+	entry.hits = 1
+	s.items[entry.key] = entry
+	if entry.pinned {
+		s.pinnedBytes += entry.size
+		s.pinnedEntries++
+		s.notePinnedExpiry(entry)
+		return
+	}
+	s.bytes += entry.size
+	heap.Push(&s.queue, entry)
+}
+
+func (s *memoryCacheShard) remove(entry *memoryCacheEntry) {
+	delete(s.items, entry.key)
+	if entry.pinned {
+		s.pinnedBytes -= entry.size
+		s.pinnedEntries--
+		return
+	}
+	s.bytes -= entry.size
+	heap.Remove(&s.queue, entry.index)
+}
+
+// touch records an access to entry for the eviction policy
+func (s *memoryCacheShard) touch(entry *memoryCacheEntry) {
+	s.clock++
+	entry.used = s.clock
+	entry.hits++
+	if !entry.pinned {
+		heap.Fix(&s.queue, entry.index)
+	}
+}
+
+// memoryCacheEntry is an item together with its eviction bookkeeping
+type memoryCacheEntry struct {
+	key  string
+	item *MemoryCacheItem
+	size int64
+	// used is the shard clock at the last access, hits the number of accesses
+	used  uint64
+	hits  uint64
+	index int
+	// pinned items are kept out of the eviction queue
+	pinned bool
+}
+
+// evictionQueue is a min-heap with the next eviction victim at the root.
+// With lfu set entries are ordered by hits first, otherwise by last access.
+type evictionQueue struct {
+	entries []*memoryCacheEntry
+	lfu     bool
+}
+
+func (q evictionQueue) Len() int { return len(q.entries) }
+
+func (q evictionQueue) Less(i, j int) bool {
+	a, b := q.entries[i], q.entries[j]
+	if q.lfu && a.hits != b.hits {
+		return a.hits < b.hits
+	}
+	return a.used < b.used
+}
+
+func (q evictionQueue) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].index = i
+	q.entries[j].index = j
+}
+
+func (q *evictionQueue) Push(x any) {
+	entry := x.(*memoryCacheEntry)
+	entry.index = len(q.entries)
+	q.entries = append(q.entries, entry)
+}
+
+func (q *evictionQueue) Pop() any {
+	n := len(q.entries)
+	entry := q.entries[n-1]
+	q.entries[n-1] = nil
+	q.entries = q.entries[:n-1]
+	return entry
 }
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMemoryCache creates a single shard cache, so limits apply exactly
+func newTestMemoryCache(t *testing.T, opts MemoryCacheOptions) *MemoryCacheService {
+	t.Helper()
+
+	opts.Shards = 1
+	cache, err := NewMemoryCacheServiceWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestMemoryCacheService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LRU should evict the least recently used key", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxEntries: 3})
+
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, cache.Set(ctx, key, key, time.Minute))
+		}
+		_, err := cache.Get(ctx, "a")
+		require.NoError(t, err)
+
+		require.NoError(t, cache.Set(ctx, "d", "d", time.Minute))
+
+		assert.Equal(t, 3, cache.Size())
+		_, err = cache.Get(ctx, "b")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		for _, key := range []string{"a", "c", "d"} {
+			exists, err := cache.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+	})
+
+	t.Run("LFU should evict the least frequently used key", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxEntries: 3, Eviction: EvictionLFU})
+
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, cache.Set(ctx, key, key, time.Minute))
+		}
+		// "a" is the oldest but the most used, "c" is used once more than "b"
+		for i := 0; i < 3; i++ {
+			_, _ = cache.Get(ctx, "a")
+		}
+		_, _ = cache.Get(ctx, "c")
+
+		require.NoError(t, cache.Set(ctx, "d", "d", time.Minute))
+
+		_, err := cache.Get(ctx, "b")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		for _, key := range []string{"a", "c", "d"} {
+			exists, err := cache.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+	})
+
+	t.Run("MaxBytes should evict until the new value fits", func(t *testing.T) {
+		value := strings.Repeat("x", 100)
+		itemSize := memoryCacheSize("k0", value)
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxBytes: 3 * itemSize})
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("k%d", i), value, time.Minute))
+		}
+		assert.Equal(t, 3, cache.Size())
+
+		require.NoError(t, cache.Set(ctx, "k3", strings.Repeat("x", 150), time.Minute))
+
+		stats := cache.Stats()
+		assert.LessOrEqual(t, stats.Bytes, 3*itemSize)
+		assert.Equal(t, uint64(2), stats.Evictions)
+		assert.Equal(t, 2, stats.Entries)
+
+		exists, err := cache.Exists(ctx, "k3")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("a value larger than MaxBytes should be rejected", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxBytes: 256})
+		require.NoError(t, cache.Set(ctx, "big", "small", time.Minute))
+
+		err := cache.Set(ctx, "big", strings.Repeat("x", 512), time.Minute)
+		assert.ErrorIs(t, err, ErrCacheValueTooLarge)
+
+		// The previous value must not survive a failed overwrite
+		_, err = cache.Get(ctx, "big")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("overwriting a key should not evict other keys", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxEntries: 2})
+		require.NoError(t, cache.Set(ctx, "a", "1", time.Minute))
+		require.NoError(t, cache.Set(ctx, "b", "1", time.Minute))
+		require.NoError(t, cache.Set(ctx, "a", "2", time.Minute))
+
+		assert.Equal(t, 2, cache.Size())
+		assert.Equal(t, uint64(0), cache.Stats().Evictions)
+	})
+
+	t.Run("pinned keys should never be evicted or count against the limits", func(t *testing.T) {
+		clock := newTestClock()
+		cache := newTestMemoryCache(t, MemoryCacheOptions{MaxEntries: 2, Pinned: IsSecurityKey})
+		cache.now = clock.Now
+
+		require.NoError(t, cache.Set(ctx, "blacklist:jti", "1", time.Minute))
+		require.NoError(t, cache.Set(ctx, "session:1:sid", "s", time.Hour))
+		for i := 0; i < 10; i++ {
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("cat:%d", i), i, time.Hour))
+		}
+
+		assert.Equal(t, 4, cache.Size())
+		assert.Equal(t, uint64(8), cache.Stats().Evictions)
+		for _, key := range []string{"blacklist:jti", "session:1:sid", "cat:8", "cat:9"} {
+			exists, err := cache.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+
+		// Pinned keys still expire
+		clock.Advance(2 * time.Minute)
+		_, err := cache.Get(ctx, "blacklist:jti")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+
+		require.NoError(t, cache.Delete(ctx, "session:1:sid"))
+		assert.Equal(t, 2, cache.Size())
+	})
+
+	t.Run("a flood of pinned keys should stay within the pinned limits", func(t *testing.T) {
+		clock := newTestClock()
+		itemSize := memoryCacheSize("blacklist:00000", "1")
+		cache := newTestMemoryCache(t, MemoryCacheOptions{
+			MaxEntries:       10,
+			Pinned:           IsSecurityKey,
+			MaxPinnedEntries: 100,
+			MaxPinnedBytes:   200 * itemSize,
+		})
+		cache.now = clock.Now
+
+		rejected := 0
+		for i := 0; i < 10000; i++ {
+			err := cache.Set(ctx, fmt.Sprintf("blacklist:%05d", i), "1", time.Minute)
+			if errors.Is(err, ErrCachePinnedFull) {
+				rejected++
+				continue
+			}
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 9900, rejected)
+		assert.Equal(t, 100, cache.Size())
+		assert.LessOrEqual(t, cache.Stats().Bytes, 100*itemSize)
+		// Nothing pinned was evicted to make room
+		exists, err := cache.Exists(ctx, "blacklist:00000")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		// Overwriting a pinned key does not need room
+		require.NoError(t, cache.Set(ctx, "blacklist:00042", "1", time.Hour))
+		// Evictable keys are not affected
+		require.NoError(t, cache.Set(ctx, "cat:1", "1", time.Minute))
+
+		// Once the flood expires there is room again
+		clock.Advance(2 * time.Minute)
+		require.NoError(t, cache.Set(ctx, "blacklist:late", "1", time.Minute))
+		assert.Equal(t, 2, cache.shards[0].pinnedEntries)
+		exists, err = cache.Exists(ctx, "blacklist:00042")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("a pinned value larger than MaxPinnedBytes should be rejected", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{Pinned: IsSecurityKey, MaxPinnedBytes: 256})
+
+		err := cache.Set(ctx, "session:1:sid", strings.Repeat("x", 512), time.Minute)
+		assert.ErrorIs(t, err, ErrCacheValueTooLarge)
+	})
+
+	t.Run("Stats should count hits, misses and expirations", func(t *testing.T) {
+		clock := newTestClock()
+		cache := newTestMemoryCache(t, MemoryCacheOptions{})
+		cache.now = clock.Now
+
+		require.NoError(t, cache.Set(ctx, "hit", "value", time.Minute))
+		require.NoError(t, cache.Set(ctx, "expiring", "value", time.Second))
+
+		_, _ = cache.Get(ctx, "hit")
+		_, _ = cache.Exists(ctx, "hit")
+		_, _ = cache.Get(ctx, "missing")
+
+		clock.Advance(2 * time.Second)
+		_, _ = cache.Get(ctx, "expiring")
+
+		stats := cache.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(2), stats.Misses)
+		assert.Equal(t, uint64(1), stats.Expirations)
+		assert.Equal(t, uint64(0), stats.Evictions)
+		assert.Equal(t, 1, stats.Entries)
+		assert.Equal(t, memoryCacheSize("hit", "value"), stats.Bytes)
+	})
+
+	t.Run("Clear should drop every key and its bytes", func(t *testing.T) {
+		cache, err := NewMemoryCacheServiceWithOptions(MemoryCacheOptions{MaxEntries: 100})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		for i := 0; i < 50; i++ {
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("key:%d", i), i, 0))
+		}
+		assert.Equal(t, 50, cache.Size())
+
+		cache.Clear()
+
+		assert.Equal(t, 0, cache.Size())
+		assert.Equal(t, int64(0), cache.Stats().Bytes)
+	})
+
+	t.Run("sharded limits should never exceed MaxEntries", func(t *testing.T) {
+		cache, err := NewMemoryCacheServiceWithOptions(MemoryCacheOptions{MaxEntries: 10, Shards: 4})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("key:%d", i), i, time.Minute))
+		}
+		assert.LessOrEqual(t, cache.Size(), 10)
+	})
+
+	t.Run("a tiny MaxEntries should reduce the shard count", func(t *testing.T) {
+		cache, err := NewMemoryCacheServiceWithOptions(MemoryCacheOptions{MaxEntries: 2})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		assert.Len(t, cache.shards, 2)
+	})
+
+	t.Run("invalid options should be rejected", func(t *testing.T) {
+		_, err := NewMemoryCacheServiceWithOptions(MemoryCacheOptions{Eviction: "fifo"})
+		assert.ErrorContains(t, err, "unsupported eviction policy")
+
+		_, err = NewMemoryCacheServiceWithOptions(MemoryCacheOptions{MaxEntries: -1})
+		assert.Error(t, err)
+	})
+}
+
+func TestCacheFactoryMemory(t *testing.T) {
+	factory := NewCacheFactory()
+
+	t.Run("CreateCacheService with memory type should apply the configured bounds", func(t *testing.T) {
+		cfg := &config.Config{Cache: config.Cache{
+			MemoryMaxEntries: 64,
+			MemoryMaxBytes:   1 << 20,
+			MemoryEviction:   "lfu",
+			MemoryShards:     4,
+
+			MemoryMaxPinnedEntries: 40,
+			MemoryMaxPinnedBytes:   1 << 16,
+		}}
+
+		cache, err := factory.CreateCacheService(CacheTypeMemory, cfg)
+		require.NoError(t, err)
+		defer cache.Close()
+
+		memory := cache.(*MemoryCacheService)
+		assert.Len(t, memory.shards, 4)
+		assert.Equal(t, 16, memory.shards[0].maxEntries)
+		assert.True(t, memory.shards[0].queue.lfu)
+		assert.Equal(t, 10, memory.shards[0].maxPinnedEntries)
+		assert.Equal(t, int64(1<<14), memory.shards[0].maxPinnedBytes)
+		assert.True(t, memory.pinned("revoked_before:1"))
+		assert.False(t, memory.pinned("cat:1"))
+	})
+
+	t.Run("CreateCacheService with an unknown eviction policy should fail", func(t *testing.T) {
+		cfg := &config.Config{Cache: config.Cache{MemoryEviction: "random"}}
+
+		cache, err := factory.CreateCacheService(CacheTypeMemory, cfg)
+		assert.Error(t, err)
+		assert.Nil(t, cache)
+	})
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// memorySnapshotVersion is bumped whenever the snapshot format changes
+const memorySnapshotVersion = 1
+
+// memorySnapshot is the on-disk representation of a MemoryCacheService
+type memorySnapshot struct {
+	Version int                   `json:"version"`
+	SavedAt time.Time             `json:"saved_at"`
+	Entries []memorySnapshotEntry `json:"entries"`
+}
+
+// memorySnapshotEntry stores the absolute expiration, so the remaining TTL
+// keeps running while the service is down. A zero ExpiresAt never expires.
+type memorySnapshotEntry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Snapshot writes every live item to w
+func (m *MemoryCacheService) Snapshot(w io.Writer) error {
+	now := m.now()
+	snapshot := memorySnapshot{Version: memorySnapshotVersion, SavedAt: now}
+
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		for key, entry := range s.items {
+			if entry.item.expiredAt(now) {
+				continue
+			}
+			value, err := memoryValueString(entry.item.Value)
+			if err != nil {
+				s.mutex.Unlock()
+				return fmt.Errorf("failed to snapshot %q: %w", key, err)
+			}
+			snapshot.Entries = append(snapshot.Entries, memorySnapshotEntry{
+				Key:       key,
+				Value:     value,
+				ExpiresAt: entry.item.ExpiresAt,
+			})
+		}
+		s.mutex.Unlock()
+	}
+
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// Restore loads the items written by Snapshot, skipping the ones that expired
+// in the meantime. It returns the number of restored items.
+func (m *MemoryCacheService) Restore(r io.Reader) (int, error) {
+	var snapshot memorySnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return 0, fmt.Errorf("failed to decode cache snapshot: %w", err)
+	}
+	if snapshot.Version != memorySnapshotVersion {
+		return 0, fmt.Errorf("unsupported cache snapshot version: %d", snapshot.Version)
+	}
+
+	ctx := context.Background()
+	now := m.now()
+	restored := 0
+	for _, entry := range snapshot.Entries {
+		var ttl time.Duration
+		if !entry.ExpiresAt.IsZero() {
+			ttl = entry.ExpiresAt.Sub(now)
+			if ttl <= 0 {
+				continue
+			}
+		}
+
+		// Items that no longer fit the configured limits are dropped
+		if err := m.Set(ctx, entry.Key, entry.Value, ttl); err != nil {
+			continue
+		}
+		restored++
+	}
+
+	return restored, nil
+}
+
+// SaveSnapshot atomically replaces the snapshot file at path. The file may
+// contain refresh tokens, so it is only readable by the owner.
+func (m *MemoryCacheService) SaveSnapshot(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = m.Snapshot(tmp); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write cache snapshot: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace cache snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot restores the snapshot file at path. A missing file is not an
+// error, it simply means there is nothing to restore yet.
+func (m *MemoryCacheService) LoadSnapshot(path string) (int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cache snapshot: %w", err)
+	}
+	defer file.Close()
+
+	return m.Restore(file)
+}
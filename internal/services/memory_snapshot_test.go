@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCacheSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LoadSnapshot should restore items with their remaining TTL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		clock := newTestClock()
+
+		source := newTestMemoryCache(t, MemoryCacheOptions{})
+		source.now = clock.Now
+		require.NoError(t, source.Set(ctx, "refresh_token:1", "token", time.Hour))
+		require.NoError(t, source.Set(ctx, "blacklist:short", "1", 10*time.Second))
+		require.NoError(t, source.Set(ctx, "forever", "1", 0))
+		require.NoError(t, source.SetJSON(ctx, "json", map[string]int{"id": 7}, time.Hour))
+		require.NoError(t, source.SaveSnapshot(path))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		// The service is down for a while before it restores the snapshot
+		clock.Advance(30 * time.Second)
+
+		restored := newTestMemoryCache(t, MemoryCacheOptions{})
+		restored.now = clock.Now
+		count, err := restored.LoadSnapshot(path)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		value, err := restored.Get(ctx, "refresh_token:1")
+		require.NoError(t, err)
+		assert.Equal(t, "token", value)
+
+		var payload map[string]int
+		require.NoError(t, restored.GetJSON(ctx, "json", &payload))
+		assert.Equal(t, 7, payload["id"])
+
+		_, err = restored.Get(ctx, "blacklist:short")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+
+		clock.Advance(time.Hour)
+		_, err = restored.Get(ctx, "refresh_token:1")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		exists, err := restored.Exists(ctx, "forever")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("SaveSnapshot should replace the previous snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		cache := newTestMemoryCache(t, MemoryCacheOptions{})
+
+		require.NoError(t, cache.Set(ctx, "old", "1", 0))
+		require.NoError(t, cache.SaveSnapshot(path))
+		require.NoError(t, cache.Delete(ctx, "old"))
+		require.NoError(t, cache.Set(ctx, "new", "1", 0))
+		require.NoError(t, cache.SaveSnapshot(path))
+
+		restored := newTestMemoryCache(t, MemoryCacheOptions{})
+		_, err := restored.LoadSnapshot(path)
+		require.NoError(t, err)
+
+		exists, _ := restored.Exists(ctx, "old")
+		assert.False(t, exists)
+		exists, _ = restored.Exists(ctx, "new")
+		assert.True(t, exists)
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files must be cleaned up")
+	})
+
+	t.Run("LoadSnapshot should respect the configured limits", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		source := newTestMemoryCache(t, MemoryCacheOptions{})
+		for _, key := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, source.Set(ctx, key, key, 0))
+		}
+		require.NoError(t, source.SaveSnapshot(path))
+
+		restored := newTestMemoryCache(t, MemoryCacheOptions{MaxEntries: 2})
+		_, err := restored.LoadSnapshot(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, restored.Size())
+	})
+
+	t.Run("LoadSnapshot on a missing file should restore nothing", func(t *testing.T) {
+		cache := newTestMemoryCache(t, MemoryCacheOptions{})
+		count, err := cache.LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("LoadSnapshot should reject corrupt or unknown snapshots", func(t *testing.T) {
+		dir := t.TempDir()
+		cache := newTestMemoryCache(t, MemoryCacheOptions{})
+
+		corrupt := filepath.Join(dir, "corrupt.json")
+		require.NoError(t, os.WriteFile(corrupt, []byte("{not json"), 0o600))
+		_, err := cache.LoadSnapshot(corrupt)
+		assert.ErrorContains(t, err, "failed to decode cache snapshot")
+
+		future := filepath.Join(dir, "future.json")
+		require.NoError(t, os.WriteFile(future, []byte(`{"version":99,"entries":[]}`), 0o600))
+		_, err = cache.LoadSnapshot(future)
+		assert.ErrorContains(t, err, "unsupported cache snapshot version")
+	})
+}
+
+func TestCacheFactoryMemorySnapshot(t *testing.T) {
+	factory := NewCacheFactory()
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	source := newTestMemoryCache(t, MemoryCacheOptions{})
+	require.NoError(t, source.Set(context.Background(), "blacklist:token", "1", time.Hour))
+	require.NoError(t, source.SaveSnapshot(path))
+
+	t.Run("CreateCacheService with memory type should restore the snapshot", func(t *testing.T) {
+		cfg := &config.Config{Cache: config.Cache{SnapshotPath: path}}
+
+		cache, err := factory.CreateCacheService(CacheTypeMemory, cfg)
+		require.NoError(t, err)
+		defer cache.Close()
+
+		exists, err := cache.Exists(context.Background(), "blacklist:token")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("CreateCacheService with memory type should fail on a corrupt snapshot", func(t *testing.T) {
+		corrupt := filepath.Join(t.TempDir(), "corrupt.json")
+		require.NoError(t, os.WriteFile(corrupt, []byte("garbage"), 0o600))
+		cfg := &config.Config{Cache: config.Cache{SnapshotPath: corrupt}}
+
+		cache, err := factory.CreateCacheService(CacheTypeMemory, cfg)
+		assert.Error(t, err)
+		assert.Nil(t, cache)
+	})
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"errors"
 
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/repo"
@@ -13,57 +12,66 @@ type MissionContext interface {
 	AssignCat(ctx context.Context, missionID, catID uint) error
 	MarkComplete(ctx context.Context, missionID uint) error
 	GetAll(ctx context.Context) ([]models.Mission, error)
+	List(ctx context.Context, filter repo.MissionFilter) ([]models.Mission, int64, error)
 	GetByID(ctx context.Context, id uint) (*models.Mission, error)
 	DeleteByID(ctx context.Context, id uint) error
 }
 
 type Mission struct {
-	repo repo.MissionRepository
+	store repo.Store
 }
 
-func NewMission(repo repo.MissionRepository) *Mission {
-	return &Mission{repo: repo}
+func NewMission(store repo.Store) *Mission {
+	return &Mission{store: store}
 }
 
 func (s *Mission) Create(ctx context.Context, m *models.Mission) error {
 	if len(m.Targets) < 1 || len(m.Targets) > 3 {
-		return errors.New("mission must have between 1 and 3 targets")
+		return ErrInvalidTargetCount
 	}
-	return s.repo.Create(ctx, m)
+	return s.store.Mission().Create(ctx, m)
 }
 
 func (s *Mission) AssignCat(ctx context.Context, missionID, catID uint) error {
-	return s.repo.AssignCat(ctx, missionID, catID)
+	return s.store.Mission().AssignCat(ctx, missionID, catID)
 }
 
 func (s *Mission) MarkComplete(ctx context.Context, missionID uint) error {
-	m, err := s.repo.FindByID(ctx, missionID)
-	if err != nil {
-		return err
-	}
-	for _, t := range m.Targets {
-		if !t.Complete {
-			return errors.New("all targets must be completed before completing mission")
+	return s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, missionID)
+		if err != nil {
+			return err
 		}
-	}
-	return s.repo.MarkComplete(ctx, missionID)
+		for _, t := range m.Targets {
+			if !t.Complete {
+				return ErrTargetsIncomplete
+			}
+		}
+		return tx.Mission().MarkComplete(ctx, missionID)
+	})
 }
 
 func (s *Mission) GetAll(ctx context.Context) ([]models.Mission, error) {
-	return s.repo.FindAll(ctx)
+	return s.store.Mission().FindAll(ctx)
+}
+
+func (s *Mission) List(ctx context.Context, filter repo.MissionFilter) ([]models.Mission, int64, error) {
+	return s.store.Mission().FindPage(ctx, filter)
 }
 
 func (s *Mission) GetByID(ctx context.Context, id uint) (*models.Mission, error) {
-	return s.repo.FindByID(ctx, id)
+	return s.store.Mission().FindByID(ctx, id)
 }
 
 func (s *Mission) DeleteByID(ctx context.Context, id uint) error {
-	m, err := s.repo.FindByID(ctx, id)
-	if err != nil {
-		return err
-	}
-	if m.CatID != nil {
-		return errors.New("cannot delete assigned mission")
-	}
-	return s.repo.DeleteByID(ctx, id)
+	return s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if m.CatID != nil {
+			return ErrMissionAssigned
+		}
+		return tx.Mission().DeleteByID(ctx, id)
+	})
 }
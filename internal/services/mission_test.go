@@ -2,17 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
 	"DevelopsToday/internal/repo/mocks"
-
-	"gorm.io/gorm"
 )
 
 func TestMissionService(t *testing.T) {
 	store := mocks.NewRepository()
-	missionService := NewMission(store.Mission())
+	missionService := NewMission(store)
 	ctx := context.Background()
 
 	t.Run("Create should create mission with valid targets", func(t *testing.T) {
@@ -53,8 +53,8 @@ func TestMissionService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for mission with no targets")
 		}
-		if err.Error() != "mission must have between 1 and 3 targets" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, ErrInvalidTargetCount) {
+			t.Fatalf("Expected ErrInvalidTargetCount, got %v", err)
 		}
 	})
 
@@ -73,8 +73,8 @@ func TestMissionService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for mission with more than 3 targets")
 		}
-		if err.Error() != "mission must have between 1 and 3 targets" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, ErrInvalidTargetCount) {
+			t.Fatalf("Expected ErrInvalidTargetCount, got %v", err)
 		}
 	})
 
@@ -102,8 +102,8 @@ func TestMissionService(t *testing.T) {
 
 	t.Run("GetByID should return error for non-existing mission", func(t *testing.T) {
 		_, err := missionService.GetByID(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
@@ -124,15 +124,22 @@ func TestMissionService(t *testing.T) {
 
 	t.Run("AssignCat should return error for non-existing mission", func(t *testing.T) {
 		err := missionService.AssignCat(ctx, 999, 1)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
 	t.Run("AssignCat should return error for non-existing cat", func(t *testing.T) {
 		err := missionService.AssignCat(ctx, 4, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrCatNotFound) {
+			t.Fatalf("Expected repo.ErrCatNotFound, got %v", err)
+		}
+	})
+
+	t.Run("AssignCat should reject a cat with an active mission", func(t *testing.T) {
+		err := missionService.AssignCat(ctx, 4, 1) // Whiskers is busy with mission 1
+		if !errors.Is(err, repo.ErrCatBusy) {
+			t.Fatalf("Expected repo.ErrCatBusy, got %v", err)
 		}
 	})
 
@@ -141,8 +148,8 @@ func TestMissionService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for mission with incomplete targets")
 		}
-		if err.Error() != "all targets must be completed before completing mission" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, ErrTargetsIncomplete) {
+			t.Fatalf("Expected ErrTargetsIncomplete, got %v", err)
 		}
 	})
 
@@ -166,8 +173,8 @@ func TestMissionService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for assigned mission")
 		}
-		if err.Error() != "cannot delete assigned mission" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, ErrMissionAssigned) {
+			t.Fatalf("Expected ErrMissionAssigned, got %v", err)
 		}
 	})
 
@@ -193,15 +200,15 @@ func TestMissionService(t *testing.T) {
 
 		// Verify mission was deleted
 		_, err = missionService.GetByID(ctx, mission.ID)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
 	t.Run("DeleteByID should return error for non-existing mission", func(t *testing.T) {
 		err := missionService.DeleteByID(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 }
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/pkg/logger"
+)
+
+// Notifier types
+const (
+	NotifierLog  = "log"
+	NotifierFile = "file"
+)
+
+// Notification is a message to a user, e.g. an email
+type Notification struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier delivers notifications to users. An email implementation plugs in
+// here, the log and file sinks are meant for local development because they
+// expose the contents, reset links included.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NewNotifier creates the notifier selected by cfg.Type
+func NewNotifier(cfg config.Notifier, l logger.Interface) (Notifier, error) {
+	switch cfg.Type {
+	case "", NotifierLog:
+		return NewLogNotifier(l), nil
+	case NotifierFile:
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("file notifier requires a file path")
+		}
+		return NewFileNotifier(cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier type: %s", cfg.Type)
+	}
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct {
+	logger logger.Interface
+}
+
+func NewLogNotifier(l logger.Interface) *LogNotifier {
+	return &LogNotifier{logger: l}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, notification Notification) error {
+	n.logger.Info("Notification to %s: %s\n%s", notification.To, notification.Subject, notification.Body)
+	return nil
+}
+
+// FileNotifier appends notifications to a file, one JSON object per line
+type FileNotifier struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(_ context.Context, notification Notification) error {
+	if notification.SentAt.IsZero() {
+		notification.SentAt = time.Now()
+	}
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"DevelopsToday/config"
+	"DevelopsToday/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the file notifier should append one JSON line per notification", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notifications.log")
+		notifier, err := NewNotifier(config.Notifier{Type: NotifierFile, FilePath: path}, logger.New("error"))
+		require.NoError(t, err)
+
+		require.NoError(t, notifier.Notify(ctx, Notification{To: "a@spycats.com", Subject: "First", Body: "one"}))
+		require.NoError(t, notifier.Notify(ctx, Notification{To: "b@spycats.com", Subject: "Second", Body: "two"}))
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		var sent []Notification
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var notification Notification
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &notification))
+			sent = append(sent, notification)
+		}
+		require.Len(t, sent, 2)
+		assert.Equal(t, "a@spycats.com", sent[0].To)
+		assert.Equal(t, "Second", sent[1].Subject)
+		assert.False(t, sent[1].SentAt.IsZero())
+	})
+
+	t.Run("the log notifier should be the default", func(t *testing.T) {
+		notifier, err := NewNotifier(config.Notifier{}, logger.New("error"))
+		require.NoError(t, err)
+		assert.IsType(t, &LogNotifier{}, notifier)
+		assert.NoError(t, notifier.Notify(ctx, Notification{To: "a@spycats.com"}))
+	})
+
+	t.Run("misconfiguration should be rejected", func(t *testing.T) {
+		_, err := NewNotifier(config.Notifier{Type: "smtp"}, logger.New("error"))
+		assert.Error(t, err)
+		_, err = NewNotifier(config.Notifier{Type: NotifierFile}, logger.New("error"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+)
+
+var (
+	// ErrWrongPassword is returned when the current password does not match
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrInvalidResetToken is returned for unknown, used, superseded or
+	// expired password reset tokens
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+)
+
+const defaultResetTokenTTL = 30 * time.Minute
+
+// passwordReset is what is stored for a reset token. Only the hash of the
+// token is used as the key, like refresh tokens.
+type passwordReset struct {
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordService sets, changes and resets passwords according to the
+// password policy. Changes and resets log the user out everywhere.
+type PasswordService struct {
+	users    repo.UserRepository
+	cache    CacheService
+	jwt      *JWTService
+	notifier Notifier
+	policy   *PasswordPolicy
+	cfg      config.PasswordReset
+	// mutex makes redeeming a token a single step on caches without
+	// CacheAtomic, where it only holds within this instance
+	mutex sync.Mutex
+}
+
+func NewPasswordService(
+	users repo.UserRepository,
+	cache CacheService,
+	jwtService *JWTService,
+	notifier Notifier,
+	policy *PasswordPolicy,
+	cfg config.PasswordReset,
+) *PasswordService {
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = defaultResetTokenTTL
+	}
+	return &PasswordService{
+		users:    users,
+		cache:    cache,
+		jwt:      jwtService,
+		notifier: notifier,
+		policy:   policy,
+		cfg:      cfg,
+	}
+}
+
+func passwordResetKey(token string) string {
+	return fmt.Sprintf("password_reset:%s", hashToken(token))
+}
+
+// passwordResetUserKey holds the hash of the latest reset token of a user,
+// issuing a new token or changing the password supersedes older ones
+func passwordResetUserKey(userID uint) string {
+	return fmt.Sprintf("password_reset:user:%d", userID)
+}
+
+// Verify reports whether password is the one of user, which may be nil, in
+// about the same time either way
+func (s *PasswordService) Verify(user *models.User, password string) bool {
+	return s.policy.Verify(user, password)
+}
+
+// Assign checks password against the policy and stores its hash in a new
+// user before it is created
+func (s *PasswordService) Assign(user *models.User, password string) error {
+	return s.policy.Hash(user, password)
+}
+
+// Upgrade re-hashes the password of a user who just logged in with it when
+// the stored hash uses another bcrypt cost than the configured one
+func (s *PasswordService) Upgrade(ctx context.Context, user *models.User, password string) error {
+	if !s.policy.NeedsRehash(user) {
+		return nil
+	}
+	current := user.Password
+	if err := s.policy.Rehash(user, password); err != nil {
+		return err
+	}
+	err := s.users.UpdatePassword(ctx, user.ID, current, user.Password)
+	if errors.Is(err, repo.ErrPasswordChanged) {
+		// Changed while logging in, the new password wins
+		return nil
+	}
+	return err
+}
+
+// Change sets a new password after checking the current one
+func (s *PasswordService) Change(ctx context.Context, userID uint, current, next string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(current) {
+		return ErrWrongPassword
+	}
+	err = s.setPassword(ctx, user, next)
+	if errors.Is(err, repo.ErrPasswordChanged) {
+		return ErrWrongPassword
+	}
+	return err
+}
+
+// RequestReset sends a reset link to the user with the given email. Unknown
+// emails are ignored so the response does not tell whether an account exists.
+func (s *PasswordService) RequestReset(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newTokenID()
+	if err != nil {
+		return err
+	}
+	reset := passwordReset{UserID: user.ID, ExpiresAt: time.Now().Add(s.cfg.TokenTTL)}
+	if err := s.cache.SetJSON(ctx, passwordResetKey(token), reset, s.cfg.TokenTTL); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+	if err := s.cache.Set(ctx, passwordResetUserKey(user.ID), hashToken(token), s.cfg.TokenTTL); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	return s.notifier.Notify(ctx, Notification{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use %s to choose a new password. It expires in %s and works once. "+
+			"If you did not ask for it, ignore this message.", s.resetLink(token), s.cfg.TokenTTL),
+	})
+}
+
+// Reset sets a new password with a token from RequestReset. A password that
+// breaks the policy leaves the token unused.
+func (s *PasswordService) Reset(ctx context.Context, token, next string) error {
+	if err := s.policy.Validate(next); err != nil {
+		return err
+	}
+
+	userID, err := s.redeem(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	err = s.setPassword(ctx, user, next)
+	if errors.Is(err, repo.ErrPasswordChanged) {
+		// A change meanwhile supersedes the token
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+// setPassword stores a new password unless the one user was loaded with has
+// been changed meanwhile, then it returns repo.ErrPasswordChanged
+func (s *PasswordService) setPassword(ctx context.Context, user *models.User, password string) error {
+	current := user.Password
+	if err := s.policy.Hash(user, password); err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, user.ID, current, user.Password); err != nil {
+		return err
+	}
+
+	// Outstanding reset links must not undo the change
+	if err := s.cache.Delete(ctx, passwordResetUserKey(user.ID)); err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	return s.jwt.RevokeAllSessions(user.ID)
+}
+
+// redeem uses up a reset token and returns its user
+func (s *PasswordService) redeem(ctx context.Context, token string) (uint, error) {
+	var reset passwordReset
+	err := takeJSON(ctx, s.cache, &s.mutex, passwordResetKey(token), &reset)
+	if errors.Is(err, ErrCacheMiss) {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to redeem password reset token: %w", err)
+	}
+
+	latest, err := s.cache.Get(ctx, passwordResetUserKey(reset.UserID))
+	if errors.Is(err, ErrCacheMiss) || (err == nil && latest != hashToken(token)) {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load password reset token: %w", err)
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		return 0, ErrInvalidResetToken
+	}
+	return reset.UserID, nil
+}
+
+// resetLink appends the token to the configured reset page, without one the
+// token is sent on its own
+func (s *PasswordService) resetLink(token string) string {
+	link, err := url.Parse(s.cfg.URL)
+	if err != nil || s.cfg.URL == "" {
+		return token
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
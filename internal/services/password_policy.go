@@ -0,0 +1,182 @@
+package services
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrWeakPassword is matched by every password policy violation
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// PasswordPolicyError lists why a password was rejected
+type PasswordPolicyError struct {
+	Reasons []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password " + strings.Join(e.Reasons, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// Policy defaults for settings left at zero
+const (
+	defaultPasswordMinLength  = 10
+	defaultPasswordMinClasses = 2
+)
+
+// maxPasswordBytes is the longest input bcrypt accepts
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+// PasswordPolicy decides which passwords are acceptable and hashes them with
+// the configured bcrypt cost
+type PasswordPolicy struct {
+	cfg    config.Password
+	common map[string]struct{}
+	// dummyHash stands in for the hash of a user that does not exist, so that
+	// checking a password takes as long either way
+	dummyHash []byte
+}
+
+// NewPasswordPolicy creates the policy from cfg. Zero settings fall back to
+// the defaults.
+func NewPasswordPolicy(cfg config.Password) (*PasswordPolicy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultPasswordMinLength
+	}
+	if cfg.MinClasses <= 0 {
+		cfg.MinClasses = defaultPasswordMinClasses
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+
+	if cfg.MinLength > maxPasswordBytes {
+		return nil, fmt.Errorf("password minimum length %d is above the bcrypt limit of %d bytes", cfg.MinLength, maxPasswordBytes)
+	}
+	if cfg.MinClasses > 4 {
+		return nil, fmt.Errorf("password character classes must be between 1 and 4, got %d", cfg.MinClasses)
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cfg.BcryptCost)
+	}
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("no such user"), cfg.BcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	policy := &PasswordPolicy{cfg: cfg, common: make(map[string]struct{}), dummyHash: dummyHash}
+	if cfg.RejectCommon {
+		for _, line := range strings.Split(commonPasswordsList, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			policy.common[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return policy, nil
+}
+
+// Validate returns a *PasswordPolicyError listing every rule password breaks
+func (p *PasswordPolicy) Validate(password string) error {
+	var reasons []string
+
+	if len([]rune(password)) < p.cfg.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		reasons = append(reasons, fmt.Sprintf("must be at most %d bytes long", maxPasswordBytes))
+	}
+	if classes := characterClasses(password); classes < p.cfg.MinClasses {
+		reasons = append(reasons, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.cfg.MinClasses))
+	}
+	if _, common := p.common[strings.ToLower(password)]; common {
+		reasons = append(reasons, "is too common")
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordPolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// Verify reports whether password is the one of user. Without a user it
+// compares against a dummy hash of the configured cost and returns false,
+// so that the time taken does not tell whether the user exists.
+func (p *PasswordPolicy) Verify(user *models.User, password string) bool {
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(p.dummyHash, []byte(password))
+		return false
+	}
+	return user.CheckPassword(password)
+}
+
+// Hash validates password and stores its hash in user
+func (p *PasswordPolicy) Hash(user *models.User, password string) error {
+	if err := p.Validate(password); err != nil {
+		return err
+	}
+
+	user.Password = password
+	if err := user.HashPasswordWithCost(p.cfg.BcryptCost); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the stored hash of user uses another cost than
+// the configured one
+func (p *PasswordPolicy) NeedsRehash(user *models.User) bool {
+	return user.NeedsRehash(p.cfg.BcryptCost)
+}
+
+// Rehash hashes the already verified password of user again with the
+// configured cost. The policy is not checked, old passwords keep working.
+func (p *PasswordPolicy) Rehash(user *models.User, password string) error {
+	user.Password = password
+	if err := user.HashPasswordWithCost(p.cfg.BcryptCost); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	return nil
+}
+
+// characterClasses counts which of lowercase letters, uppercase letters,
+// digits and other characters password contains
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Run("zero settings should fall back to the defaults", func(t *testing.T) {
+		policy, err := NewPasswordPolicy(config.Password{})
+		require.NoError(t, err)
+
+		assert.Equal(t, defaultPasswordMinLength, policy.cfg.MinLength)
+		assert.Equal(t, defaultPasswordMinClasses, policy.cfg.MinClasses)
+		assert.Equal(t, bcrypt.DefaultCost, policy.cfg.BcryptCost)
+		assert.Empty(t, policy.common)
+	})
+
+	t.Run("invalid settings should be rejected", func(t *testing.T) {
+		for name, cfg := range map[string]config.Password{
+			"length above the bcrypt limit": {MinLength: 73},
+			"too many character classes":    {MinClasses: 5},
+			"cost too low":                  {BcryptCost: 1},
+			"cost too high":                 {BcryptCost: bcrypt.MaxCost + 1},
+		} {
+			_, err := NewPasswordPolicy(cfg)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Validate should list every broken rule", func(t *testing.T) {
+		policy, err := NewPasswordPolicy(config.Password{MinLength: 12, MinClasses: 3, RejectCommon: true})
+		require.NoError(t, err)
+
+		tests := []struct {
+			password string
+			reasons  []string
+		}{
+			{"Tr0ub4dor&3x", nil},
+			{"Short1!", []string{"must be at least 12 characters long"}},
+			{"onlylowercaseletters", []string{"must mix at least 3 of lowercase letters, uppercase letters, digits and symbols"}},
+			{"password", []string{
+				"must be at least 12 characters long",
+				"must mix at least 3 of lowercase letters, uppercase letters, digits and symbols",
+				"is too common",
+			}},
+			{string(make([]byte, 73)), []string{
+				"must be at most 72 bytes long",
+				"must mix at least 3 of lowercase letters, uppercase letters, digits and symbols",
+			}},
+		}
+		for _, tt := range tests {
+			err := policy.Validate(tt.password)
+			if tt.reasons == nil {
+				assert.NoError(t, err, tt.password)
+				continue
+			}
+
+			var policyErr *PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr, tt.password)
+			assert.Equal(t, tt.reasons, policyErr.Reasons, tt.password)
+			assert.ErrorIs(t, err, ErrWeakPassword)
+		}
+	})
+
+	t.Run("common passwords should be rejected regardless of case", func(t *testing.T) {
+		policy, err := NewPasswordPolicy(config.Password{MinLength: 6, MinClasses: 1, RejectCommon: true})
+		require.NoError(t, err)
+
+		for _, password := range []string{"qwerty123", "Qwerty123", "iloveyou"} {
+			assert.ErrorIs(t, policy.Validate(password), ErrWeakPassword, password)
+		}
+
+		lenient, err := NewPasswordPolicy(config.Password{MinLength: 6, MinClasses: 1})
+		require.NoError(t, err)
+		assert.NoError(t, lenient.Validate("qwerty123"))
+	})
+
+	t.Run("Hash should use the configured cost", func(t *testing.T) {
+		policy, err := NewPasswordPolicy(config.Password{BcryptCost: bcrypt.MinCost})
+		require.NoError(t, err)
+
+		user := &models.User{}
+		assert.ErrorIs(t, policy.Hash(user, "weak"), ErrWeakPassword)
+		assert.Empty(t, user.Password)
+
+		require.NoError(t, policy.Hash(user, "agent-password"))
+		assert.True(t, user.CheckPassword("agent-password"))
+		assert.False(t, policy.NeedsRehash(user))
+
+		stricter, err := NewPasswordPolicy(config.Password{BcryptCost: bcrypt.MinCost + 1})
+		require.NoError(t, err)
+		assert.True(t, stricter.NeedsRehash(user))
+
+		require.NoError(t, stricter.Rehash(user, "agent-password"))
+		assert.False(t, stricter.NeedsRehash(user))
+		assert.True(t, user.CheckPassword("agent-password"))
+	})
+
+	t.Run("Verify without a user should still compare a hash of the configured cost", func(t *testing.T) {
+		policy, err := NewPasswordPolicy(config.Password{BcryptCost: bcrypt.MinCost + 1})
+		require.NoError(t, err)
+
+		cost, err := bcrypt.Cost(policy.dummyHash)
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.MinCost+1, cost)
+		assert.False(t, policy.Verify(nil, "no such user"))
+
+		user := &models.User{}
+		require.NoError(t, policy.Hash(user, "agent-password"))
+		assert.True(t, policy.Verify(user, "agent-password"))
+		assert.False(t, policy.Verify(user, "other-password"))
+	})
+}
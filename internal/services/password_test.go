@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+	"DevelopsToday/internal/repo/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+func TestPasswordService(t *testing.T) {
+	ctx := context.Background()
+	device := DeviceInfo{Device: "laptop"}
+
+	setup := func(t *testing.T) (*PasswordService, *JWTService, *recordingNotifier, *models.User) {
+		store := mocks.NewRepository()
+		jwtService := newTestJWTService(t)
+		notifier := &recordingNotifier{}
+
+		user := &models.User{Username: "whiskers", Email: "whiskers@spycats.com", Password: "old-password", Role: "agent"}
+		require.NoError(t, store.User().Create(ctx, user))
+
+		policy, err := NewPasswordPolicy(config.Password{RejectCommon: true, BcryptCost: bcrypt.MinCost})
+		require.NoError(t, err)
+
+		passwords := NewPasswordService(store.User(), jwtService.cache, jwtService, notifier, policy, config.PasswordReset{
+			TokenTTL: time.Minute,
+			URL:      "https://spycats.example/reset-password",
+		})
+		return passwords, jwtService, notifier, user
+	}
+
+	login := func(t *testing.T, passwords *PasswordService, password string) bool {
+		user, err := passwords.users.FindByUsername(ctx, "whiskers")
+		require.NoError(t, err)
+		return user.CheckPassword(password)
+	}
+
+	// requestToken asks for a reset link and returns the token in it
+	requestToken := func(t *testing.T, passwords *PasswordService, notifier *recordingNotifier) string {
+		require.NoError(t, passwords.RequestReset(ctx, "Whiskers@SpyCats.com"))
+		require.NotEmpty(t, notifier.notifications)
+
+		notification := notifier.notifications[len(notifier.notifications)-1]
+		assert.Equal(t, "whiskers@spycats.com", notification.To)
+		assert.Contains(t, notification.Body, "https://spycats.example/reset-password?token=")
+
+		match := resetTokenPattern.FindStringSubmatch(notification.Body)
+		require.Len(t, match, 2)
+		return match[1]
+	}
+
+	t.Run("Change should check the current password and revoke sessions", func(t *testing.T) {
+		passwords, jwtService, _, user := setup(t)
+
+		tokens, err := jwtService.GenerateTokenPair(user.ID, user.Username, user.Role, device)
+		require.NoError(t, err)
+
+		err = passwords.Change(ctx, user.ID, "wrong-password", "new-password")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		assert.True(t, login(t, passwords, "old-password"))
+
+		time.Sleep(5 * time.Millisecond)
+		require.NoError(t, passwords.Change(ctx, user.ID, "old-password", "new-password"))
+		assert.True(t, login(t, passwords, "new-password"))
+		assert.False(t, login(t, passwords, "old-password"))
+
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, device)
+		assert.Error(t, err)
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("a reset token should work once", func(t *testing.T) {
+		passwords, _, notifier, _ := setup(t)
+		token := requestToken(t, passwords, notifier)
+
+		require.NoError(t, passwords.Reset(ctx, token, "new-password"))
+		assert.True(t, login(t, passwords, "new-password"))
+
+		err := passwords.Reset(ctx, token, "another-password")
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		assert.True(t, login(t, passwords, "new-password"))
+	})
+
+	t.Run("a newer reset token or a password change should supersede a token", func(t *testing.T) {
+		passwords, _, notifier, user := setup(t)
+
+		first := requestToken(t, passwords, notifier)
+		second := requestToken(t, passwords, notifier)
+		assert.ErrorIs(t, passwords.Reset(ctx, first, "new-password"), ErrInvalidResetToken)
+
+		require.NoError(t, passwords.Change(ctx, user.ID, "old-password", "changed-password"))
+		assert.ErrorIs(t, passwords.Reset(ctx, second, "new-password"), ErrInvalidResetToken)
+		assert.True(t, login(t, passwords, "changed-password"))
+	})
+
+	t.Run("unknown emails and tokens should be rejected quietly", func(t *testing.T) {
+		passwords, _, notifier, _ := setup(t)
+
+		require.NoError(t, passwords.RequestReset(ctx, "nobody@spycats.com"))
+		assert.Empty(t, notifier.notifications)
+
+		assert.ErrorIs(t, passwords.Reset(ctx, "made-up-token", "new-password"), ErrInvalidResetToken)
+	})
+
+	t.Run("reset tokens of deleted users should be rejected", func(t *testing.T) {
+		passwords, _, notifier, user := setup(t)
+		token := requestToken(t, passwords, notifier)
+
+		require.NoError(t, passwords.users.DeleteByID(ctx, user.ID))
+		assert.ErrorIs(t, passwords.Reset(ctx, token, "new-password"), ErrInvalidResetToken)
+		_, err := passwords.users.FindByID(ctx, user.ID)
+		assert.ErrorIs(t, err, repo.ErrUserNotFound)
+	})
+
+	t.Run("a token redeemed meanwhile on another instance should work only once", func(t *testing.T) {
+		passwords, _, notifier, _ := setup(t)
+		token := requestToken(t, passwords, notifier)
+
+		// The other instance shares only the cache and the database
+		other := NewPasswordService(passwords.users, passwords.cache, passwords.jwt, nil, passwords.policy, passwords.cfg)
+		var raced error
+		passwords.cache = &racingCache{MemoryCacheService: passwords.cache.(*MemoryCacheService), prefix: "password_reset:", race: func() {
+			raced = other.Reset(ctx, token, "other-password")
+		}}
+
+		err := passwords.Reset(ctx, token, "new-password")
+		require.NoError(t, err)
+		assert.ErrorIs(t, raced, ErrInvalidResetToken)
+		assert.True(t, login(t, passwords, "new-password"))
+	})
+
+	t.Run("weak passwords should be rejected without using up the token", func(t *testing.T) {
+		passwords, _, notifier, user := setup(t)
+
+		err := passwords.Change(ctx, user.ID, "old-password", "short")
+		var policyErr *PasswordPolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		assert.True(t, login(t, passwords, "old-password"))
+
+		token := requestToken(t, passwords, notifier)
+		assert.ErrorIs(t, passwords.Reset(ctx, token, "password123"), ErrWeakPassword)
+		require.NoError(t, passwords.Reset(ctx, token, "new-password"))
+		assert.True(t, login(t, passwords, "new-password"))
+	})
+
+	t.Run("Upgrade should rehash passwords stored with another cost", func(t *testing.T) {
+		passwords, _, _, user := setup(t)
+
+		// Created through BeforeCreate with the default cost
+		stored, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, passwords.policy.NeedsRehash(stored))
+
+		require.NoError(t, passwords.Upgrade(ctx, stored, "old-password"))
+		upgraded, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		cost, err := bcrypt.Cost([]byte(upgraded.Password))
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.MinCost, cost)
+		assert.True(t, upgraded.CheckPassword("old-password"))
+
+		// Nothing changes once the cost matches
+		require.NoError(t, passwords.Upgrade(ctx, upgraded, "old-password"))
+		again, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, upgraded.Password, again.Password)
+	})
+
+	t.Run("Upgrade should only write the password and keep a newer one", func(t *testing.T) {
+		passwords, _, _, user := setup(t)
+
+		// Loaded at login, before the role and then the password changed
+		stale, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		promoted := *stale
+		promoted.Role = "admin"
+		require.NoError(t, passwords.users.Update(ctx, &promoted))
+
+		require.NoError(t, passwords.Upgrade(ctx, stale, "old-password"))
+		upgraded, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", upgraded.Role)
+		assert.True(t, upgraded.CheckPassword("old-password"))
+
+		require.NoError(t, passwords.Change(ctx, user.ID, "old-password", "new-password"))
+		require.NoError(t, passwords.Upgrade(ctx, stale, "old-password"))
+		assert.True(t, login(t, passwords, "new-password"))
+	})
+
+	t.Run("a password changed meanwhile should not be overwritten", func(t *testing.T) {
+		passwords, _, _, user := setup(t)
+
+		stale, err := passwords.users.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, passwords.Change(ctx, user.ID, "old-password", "new-password"))
+
+		assert.ErrorIs(t, passwords.setPassword(ctx, stale, "another-password"), repo.ErrPasswordChanged)
+		assert.True(t, login(t, passwords, "new-password"))
+	})
+}
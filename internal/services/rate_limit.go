@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Requests per Window
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimitResult is the outcome of counting one request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the current window ends
+	Reset time.Duration
+}
+
+// RateLimiter counts requests in sliding windows. The count is the requests
+// of the current fixed window plus those of the previous one, weighted by how
+// much of it still overlaps the sliding window.
+//
+// Counters are kept in the cache when it implements CacheCounter (Redis and
+// the tiered cache), so limits hold across every instance. Other caches, and
+// Redis while it is unavailable, fall back to counting in process memory.
+type RateLimiter struct {
+	counter CacheCounter
+	local   *localCounters
+	now     func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter on top of cache
+func NewRateLimiter(cache CacheService) *RateLimiter {
+	limiter := &RateLimiter{
+		local: newLocalCounters(),
+		now:   time.Now,
+	}
+	if counter, ok := cache.(CacheCounter); ok {
+		limiter.counter = counter
+	}
+	return limiter
+}
+
+func rateLimitKey(name, subject string, window int64) string {
+	return fmt.Sprintf("ratelimit:%s:%s:%d", name, subject, window)
+}
+
+// Allow counts a request of subject against the limit called name. When the
+// cache fails the request is counted in memory, and the result is returned
+// together with the error.
+func (l *RateLimiter) Allow(ctx context.Context, name, subject string, limit RateLimit) (RateLimitResult, error) {
+	now := l.now()
+	window := now.UnixNano() / int64(limit.Window)
+	elapsed := time.Duration(now.UnixNano() - window*int64(limit.Window))
+
+	current := rateLimitKey(name, subject, window)
+	previous := rateLimitKey(name, subject, window-1)
+
+	var count, weighted int64
+	var cacheErr error
+	if l.counter != nil {
+		var err error
+		count, weighted, err = l.count(ctx, l.counter, current, previous, limit.Window)
+		if err != nil {
+			cacheErr = fmt.Errorf("rate limit counted in memory: %w", err)
+		}
+	}
+	if l.counter == nil || cacheErr != nil {
+		// Counting in memory does not fail
+		count, weighted, _ = l.count(ctx, l.local, current, previous, limit.Window)
+	}
+
+	// The previous window still covers this part of the sliding window
+	overlap := float64(limit.Window-elapsed) / float64(limit.Window)
+	used := int(float64(weighted)*overlap) + int(count)
+
+	return RateLimitResult{
+		Allowed:   used <= limit.Requests,
+		Limit:     limit.Requests,
+		Remaining: max(limit.Requests-used, 0),
+		Reset:     limit.Window - elapsed,
+	}, cacheErr
+}
+
+func (l *RateLimiter) count(ctx context.Context, counter CacheCounter, current, previous string, window time.Duration) (int64, int64, error) {
+	// Keep each window for as long as it is the previous one
+	count, err := counter.Increment(ctx, current, 2*window)
+	if err != nil {
+		return 0, 0, err
+	}
+	weighted, err := counter.Counter(ctx, previous)
+	if err != nil {
+		return 0, 0, err
+	}
+	return count, weighted, nil
+}
+
+// localCounters is a CacheCounter in process memory
+type localCounters struct {
+	counts    map[string]localCount
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+type localCount struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// localSweepInterval is how often expired counters are dropped
+const localSweepInterval = time.Minute
+
+func newLocalCounters() *localCounters {
+	return &localCounters{
+		counts:    make(map[string]localCount),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *localCounters) Increment(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	c.sweep(now)
+
+	entry := c.counts[key]
+	if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+		entry = localCount{}
+	}
+	entry.value++
+	entry.expiresAt = time.Time{}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	c.counts[key] = entry
+	return entry.value, nil
+}
+
+func (c *localCounters) Counter(_ context.Context, key string) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.counts[key]
+	if !ok || (!entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt)) {
+		return 0, nil
+	}
+	return entry.value, nil
+}
+
+// Delete drops the counter at key
+func (c *localCounters) Delete(_ context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.counts, key)
+	return nil
+}
+
+// sweep drops expired counters, at most once per localSweepInterval
+func (c *localCounters) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < localSweepInterval {
+		return
+	}
+	c.lastSweep = now
+
+	for key, entry := range c.counts {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(c.counts, key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	limit := RateLimit{Requests: 3, Window: time.Minute}
+
+	// Start of a window, so the tests do not depend on the wall clock
+	start := time.Unix(0, 0).Add(1000 * time.Minute)
+	withClock := func(limiter *RateLimiter) *time.Time {
+		now := start
+		limiter.now = func() time.Time { return now }
+		return &now
+	}
+
+	newMemoryLimiter := func(t *testing.T) *RateLimiter {
+		cache := NewMemoryCacheService()
+		t.Cleanup(func() { cache.Close() })
+		return NewRateLimiter(cache)
+	}
+
+	t.Run("requests over the limit should be rejected", func(t *testing.T) {
+		limiter := newMemoryLimiter(t)
+		withClock(limiter)
+
+		for i := 2; i >= 0; i-- {
+			result, err := limiter.Allow(ctx, "test", "client", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 3, result.Limit)
+			assert.Equal(t, i, result.Remaining)
+			assert.Equal(t, time.Minute, result.Reset)
+		}
+
+		result, err := limiter.Allow(ctx, "test", "client", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Zero(t, result.Remaining)
+
+		// Other subjects and other limits are counted separately
+		result, err = limiter.Allow(ctx, "test", "other", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		result, err = limiter.Allow(ctx, "other", "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("the previous window should count by its overlap", func(t *testing.T) {
+		limiter := newMemoryLimiter(t)
+		now := withClock(limiter)
+
+		for i := 0; i < 3; i++ {
+			_, err := limiter.Allow(ctx, "test", "client", limit)
+			require.NoError(t, err)
+		}
+
+		// A third into the next window two thirds of the previous one count
+		*now = start.Add(time.Minute + 20*time.Second)
+		result, err := limiter.Allow(ctx, "test", "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, 40*time.Second, result.Reset)
+
+		result, err = limiter.Allow(ctx, "test", "client", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		// Two windows later nothing is left
+		*now = start.Add(3 * time.Minute)
+		result, err = limiter.Allow(ctx, "test", "client", limit)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Remaining)
+	})
+
+	t.Run("Redis counters should be shared between instances", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+
+		first := NewRateLimiter(NewRedisCacheService(client))
+		second := NewRateLimiter(NewRedisCacheService(client))
+		withClock(first)
+		withClock(second)
+
+		for i := 0; i < 3; i++ {
+			_, err := first.Allow(ctx, "test", "client", limit)
+			require.NoError(t, err)
+		}
+
+		result, err := second.Allow(ctx, "test", "client", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("an unavailable Redis should fall back to counting in memory", func(t *testing.T) {
+		mr, err := miniredis.Run()
+		require.NoError(t, err)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+		defer client.Close()
+
+		limiter := NewRateLimiter(NewRedisCacheService(client))
+		withClock(limiter)
+		mr.Close()
+
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow(ctx, "test", "client", limit)
+			assert.Error(t, err)
+			assert.True(t, result.Allowed)
+		}
+
+		result, err := limiter.Allow(ctx, "test", "client", limit)
+		assert.Error(t, err)
+		assert.False(t, result.Allowed)
+	})
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"DevelopsToday/config"
+)
+
+// Permission is an action a role may be allowed to perform
+type Permission string
+
+const (
+	PermissionCatsWrite       Permission = "cats:write"
+	PermissionSalaryUpdate    Permission = "salary:update"
+	PermissionMissionsWrite   Permission = "missions:write"
+	PermissionMissionsAssign  Permission = "missions:assign"
+	PermissionTargetsWrite    Permission = "targets:write"
+	PermissionTargetsNotes    Permission = "targets:notes"
+	PermissionTargetsComplete Permission = "targets:complete"
+	PermissionUsersManage     Permission = "users:manage"
+
+	// PermissionAll grants every permission
+	PermissionAll Permission = "*"
+)
+
+var knownPermissions = map[Permission]struct{}{
+	PermissionCatsWrite:       {},
+	PermissionSalaryUpdate:    {},
+	PermissionMissionsWrite:   {},
+	PermissionMissionsAssign:  {},
+	PermissionTargetsWrite:    {},
+	PermissionTargetsNotes:    {},
+	PermissionTargetsComplete: {},
+	PermissionUsersManage:     {},
+	PermissionAll:             {},
+}
+
+// DefaultRoles are used when no roles are configured. Agents are the spy
+// cats in the field, managers run the agency and admins also manage users.
+var DefaultRoles = map[string]string{
+	"admin":   "*",
+	"manager": "cats:write,salary:update,missions:write,missions:assign,targets:write,targets:notes,targets:complete",
+	"agent":   "targets:notes,targets:complete",
+}
+
+// DefaultRegistrationRole is the role of self-registered users when none is
+// configured
+const DefaultRegistrationRole = "agent"
+
+// legacyRoles were given to users before roles were configurable. Unless
+// they are configured, users still holding one get the default role's
+// permissions.
+var legacyRoles = map[string]struct{}{
+	"user": {},
+	"spy":  {},
+}
+
+// RBAC maps roles to the permissions they grant. Reading is allowed to every
+// authenticated user, permissions only guard changes.
+type RBAC struct {
+	roles       map[string]map[Permission]struct{}
+	defaultRole string
+}
+
+// NewRBAC creates the role definitions. cfg.Roles maps role names to comma
+// separated lists of permissions, an empty map falls back to DefaultRoles.
+func NewRBAC(cfg config.RBAC) (*RBAC, error) {
+	roles := cfg.Roles
+	if len(roles) == 0 {
+		roles = DefaultRoles
+	}
+
+	rbac := &RBAC{
+		roles:       make(map[string]map[Permission]struct{}, len(roles)),
+		defaultRole: cfg.DefaultRole,
+	}
+	if rbac.defaultRole == "" {
+		rbac.defaultRole = DefaultRegistrationRole
+	}
+
+	for role, list := range roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return nil, errors.New("empty role name")
+		}
+
+		permissions := make(map[Permission]struct{})
+		for _, name := range strings.Split(list, ",") {
+			permission := Permission(strings.TrimSpace(name))
+			if permission == "" {
+				continue
+			}
+			if _, ok := knownPermissions[permission]; !ok {
+				return nil, fmt.Errorf("role %s: unknown permission %q", role, permission)
+			}
+			permissions[permission] = struct{}{}
+		}
+		rbac.roles[role] = permissions
+	}
+
+	if !rbac.HasRole(rbac.defaultRole) {
+		return nil, fmt.Errorf("default role %s is not defined", rbac.defaultRole)
+	}
+	return rbac, nil
+}
+
+// DefaultRole returns the role self-registered users get. It should be the
+// role with the fewest permissions.
+func (r *RBAC) DefaultRole() string {
+	return r.defaultRole
+}
+
+// HasRole reports whether role is defined
+func (r *RBAC) HasRole(role string) bool {
+	_, ok := r.roles[role]
+	return ok
+}
+
+// Can reports whether role grants permission. Unknown roles grant nothing.
+func (r *RBAC) Can(role string, permission Permission) bool {
+	permissions, ok := r.roles[r.resolve(role)]
+	if !ok {
+		return false
+	}
+	if _, all := permissions[PermissionAll]; all {
+		return true
+	}
+	_, granted := permissions[permission]
+	return granted
+}
+
+// resolve maps a legacy role that is not configured to the default role
+func (r *RBAC) resolve(role string) string {
+	if _, legacy := legacyRoles[role]; legacy && !r.HasRole(role) {
+		return r.defaultRole
+	}
+	return role
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+
+	"DevelopsToday/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC(t *testing.T) {
+	t.Run("default roles should grant their permissions", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+
+		assert.True(t, rbac.Can("admin", PermissionUsersManage))
+		assert.True(t, rbac.Can("admin", PermissionCatsWrite))
+
+		assert.True(t, rbac.Can("manager", PermissionSalaryUpdate))
+		assert.True(t, rbac.Can("manager", PermissionMissionsAssign))
+		assert.False(t, rbac.Can("manager", PermissionUsersManage))
+
+		assert.True(t, rbac.Can("agent", PermissionTargetsNotes))
+		assert.False(t, rbac.Can("agent", PermissionCatsWrite))
+		assert.False(t, rbac.Can("agent", PermissionSalaryUpdate))
+	})
+
+	t.Run("unknown roles should grant nothing", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+
+		assert.False(t, rbac.HasRole("superuser"))
+		assert.False(t, rbac.Can("superuser", PermissionTargetsNotes))
+		assert.False(t, rbac.Can("", PermissionTargetsNotes))
+	})
+
+	t.Run("legacy roles should get the permissions of the default role", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+
+		for _, role := range []string{"user", "spy"} {
+			assert.True(t, rbac.Can(role, PermissionTargetsNotes), role)
+			assert.True(t, rbac.Can(role, PermissionTargetsComplete), role)
+			assert.False(t, rbac.Can(role, PermissionCatsWrite), role)
+			// They cannot be given to anyone anymore
+			assert.False(t, rbac.HasRole(role), role)
+		}
+	})
+
+	t.Run("a configured legacy role should keep its own permissions", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{
+			Roles:       map[string]string{"admin": "*", "user": "cats:write", "agent": "targets:notes"},
+			DefaultRole: "agent",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, rbac.Can("user", PermissionCatsWrite))
+		assert.False(t, rbac.Can("user", PermissionTargetsNotes))
+		assert.True(t, rbac.Can("spy", PermissionTargetsNotes))
+	})
+
+	t.Run("configured roles should replace the defaults", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{
+			Roles: map[string]string{
+				"admin":   "*",
+				"analyst": " targets:notes , missions:assign",
+			},
+			DefaultRole: "analyst",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, rbac.HasRole("analyst"))
+		assert.True(t, rbac.Can("analyst", PermissionTargetsNotes))
+		assert.True(t, rbac.Can("analyst", PermissionMissionsAssign))
+		assert.False(t, rbac.Can("analyst", PermissionCatsWrite))
+		assert.False(t, rbac.HasRole("manager"))
+	})
+
+	t.Run("unknown permissions should be rejected", func(t *testing.T) {
+		_, err := NewRBAC(config.RBAC{Roles: map[string]string{"agent": "targets:note"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("the default role should be defined", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+		assert.Equal(t, "agent", rbac.DefaultRole())
+
+		_, err = NewRBAC(config.RBAC{Roles: map[string]string{"admin": "*"}})
+		assert.Error(t, err)
+	})
+}
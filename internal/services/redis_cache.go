@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -23,12 +24,20 @@ func NewRedisCacheService(client *redis.Client) CacheService {
 
 // Set stores a key-value pair with optional TTL
 func (r *RedisCacheService) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	// go-redis reads negative durations as KEEPTTL, keep "ttl <= 0 never expires"
+	if ttl < 0 {
+		ttl = 0
+	}
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
 // Get retrieves a value by key
 func (r *RedisCacheService) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	result, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return result, err
 }
 
 // Delete removes a key from cache
@@ -66,6 +75,97 @@ func (r *RedisCacheService) GetJSON(ctx context.Context, key string, dest interf
 	return nil
 }
 
+// Increment adds one to the counter at key
+func (r *RedisCacheService) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrementRedis(ctx, r.client, key, ttl)
+}
+
+// Counter returns the value of the counter at key
+func (r *RedisCacheService) Counter(ctx context.Context, key string) (int64, error) {
+	return redisCounter(ctx, r.client, key)
+}
+
+func incrementRedis(ctx context.Context, client *redis.Client, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func redisCounter(ctx context.Context, client *redis.Client, key string) (int64, error) {
+	value, err := client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return value, err
+}
+
+// CompareAndSwap replaces the value at key when it equals old
+func (r *RedisCacheService) CompareAndSwap(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	return compareAndSwapRedis(ctx, r.client, key, old, value, ttl)
+}
+
+// SetIfAbsent stores value when key does not exist
+func (r *RedisCacheService) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return setIfAbsentRedis(ctx, r.client, key, value, ttl)
+}
+
+func setIfAbsentRedis(ctx context.Context, client *redis.Client, key, value string, ttl time.Duration) (bool, error) {
+	// go-redis reads negative durations as KEEPTTL, keep "ttl <= 0 never expires"
+	if ttl < 0 {
+		ttl = 0
+	}
+	return client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// GetDelete returns and removes the value at key
+func (r *RedisCacheService) GetDelete(ctx context.Context, key string) (string, error) {
+	return getDeleteRedis(ctx, r.client, key)
+}
+
+func getDeleteRedis(ctx context.Context, client *redis.Client, key string) (string, error) {
+	value, err := client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return value, err
+}
+
+// compareAndSwapScript sets KEYS[1] to ARGV[2] if it holds ARGV[1], with a
+// TTL of ARGV[3] milliseconds unless that is 0
+var compareAndSwapScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+func compareAndSwapRedis(ctx context.Context, client *redis.Client, key, old, value string, ttl time.Duration) (bool, error) {
+	var millis int64
+	if ttl > 0 {
+		// PX rejects 0, so round sub-millisecond TTLs up
+		millis = int64((ttl + time.Millisecond - 1) / time.Millisecond)
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, client, []string{key}, old, value, millis).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
 // Ping checks if the cache service is available
 func (r *RedisCacheService) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrTokenRevoked is returned for tokens that were blacklisted or issued
+// before the user's revocation watermark
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// blacklistKey identifies a single token. Tokens are keyed by their jti, so
+// that the bearer credential itself never ends up in the cache. Tokens
+// issued before every token had a jti fall back to a hash.
+func blacklistKey(claims *Claims, tokenString string) string {
+	if claims.ID != "" {
+		return fmt.Sprintf("blacklist:%s", claims.ID)
+	}
+	return fmt.Sprintf("blacklist:sha256:%s", hashToken(tokenString))
+}
+
+// revokedBeforeKey holds the user's watermark in unix seconds: tokens issued
+// in an earlier second are no longer accepted
+func revokedBeforeKey(userID uint) string {
+	return fmt.Sprintf("revoked_before:%d", userID)
+}
+
+// BlacklistToken revokes a single access token until it expires
+func (j *JWTService) BlacklistToken(tokenString string) error {
+	claims, err := j.ValidateToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return j.cache.Set(context.Background(), blacklistKey(claims, tokenString), "1", ttl)
+}
+
+// RevokeTokensIssuedBefore invalidates every access and refresh token of a
+// user issued before the given time, without having to list them. Tokens
+// carry their issue time in whole seconds, so the watermark is truncated to
+// the second: tokens issued in that second stay valid, which lets the user
+// log in again right away. RevokeAllSessions catches the earlier ones of
+// that second through their session.
+func (j *JWTService) RevokeTokensIssuedBefore(userID uint, before time.Time) error {
+	ctx := context.Background()
+	before = before.Truncate(time.Second)
+
+	// Every token issued before the watermark has expired after the longest TTL
+	ttl := j.refreshTTL()
+	if j.accessTTL() > ttl {
+		ttl = j.accessTTL()
+	}
+
+	value := strconv.FormatInt(before.Unix(), 10)
+	if err := j.raiseWatermark(ctx, revokedBeforeKey(userID), before, value, ttl); err != nil {
+		return fmt.Errorf("failed to store revocation watermark: %w", err)
+	}
+	return nil
+}
+
+// raiseWatermark stores value at key unless the watermark there is already
+// at or after before, so that concurrent revocations never move it back.
+// Every lost race means another revocation stored a later watermark, or the
+// key expired, so retrying ends.
+func (j *JWTService) raiseWatermark(ctx context.Context, key string, before time.Time, value string, ttl time.Duration) error {
+	atomic, ok := j.cache.(CacheAtomic)
+	for {
+		stored, err := j.cache.Get(ctx, key)
+		if errors.Is(err, ErrCacheMiss) {
+			if !ok {
+				return j.cache.Set(ctx, key, value, ttl)
+			}
+			added, err := atomic.SetIfAbsent(ctx, key, value, ttl)
+			if err != nil || added {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		current, err := parseWatermark(stored)
+		if err != nil {
+			return err
+		}
+		if !before.After(current) {
+			return nil
+		}
+		if !ok {
+			return j.cache.Set(ctx, key, value, ttl)
+		}
+		swapped, err := atomic.CompareAndSwap(ctx, key, stored, value, ttl)
+		if err != nil || swapped {
+			return err
+		}
+	}
+}
+
+// revokedBefore returns the user's watermark, the zero time when there is none
+func (j *JWTService) revokedBefore(ctx context.Context, userID uint) (time.Time, error) {
+	value, err := j.cache.Get(ctx, revokedBeforeKey(userID))
+	if errors.Is(err, ErrCacheMiss) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load revocation watermark: %w", err)
+	}
+
+	return parseWatermark(value)
+}
+
+func parseWatermark(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid revocation watermark %q: %w", value, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// issuedBeforeWatermark reports whether claims belong to a token issued
+// before the user's watermark. Tokens without iat cannot be told apart and
+// count as revoked once a watermark exists.
+func (j *JWTService) issuedBeforeWatermark(ctx context.Context, claims *Claims) (bool, error) {
+	watermark, err := j.revokedBefore(ctx, claims.UserID)
+	if err != nil || watermark.IsZero() {
+		return false, err
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Time.Unix() < watermark.Unix(), nil
+}
+
+// AuthenticateToken validates an access token and checks it has not been
+// revoked. It returns ErrTokenRevoked for blacklisted tokens, tokens issued
+// before the user's watermark and tokens whose session has been logged out.
+// Revocation is best effort: when the cache is unavailable the token is
+// accepted rather than locking every user out.
+func (j *JWTService) AuthenticateToken(tokenString string) (*Claims, error) {
+	claims, err := j.ValidateToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	if blacklisted, err := j.cache.Exists(ctx, blacklistKey(claims, tokenString)); err == nil && blacklisted {
+		return nil, ErrTokenRevoked
+	}
+	if revoked, err := j.issuedBeforeWatermark(ctx, claims); err == nil && revoked {
+		return nil, ErrTokenRevoked
+	}
+	// Tokens issued before sessions existed carry no session ID
+	if claims.SessionID != "" {
+		if live, err := j.cache.Exists(ctx, sessionKey(claims.UserID, claims.SessionID)); err == nil && !live {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTRevocation(t *testing.T) {
+	device := DeviceInfo{Device: "laptop"}
+
+	t.Run("the blacklist should be keyed by jti, not by the token", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+		require.NotEmpty(t, claims.ID)
+
+		require.NoError(t, jwtService.BlacklistToken(tokens.AccessToken))
+
+		ctx := context.Background()
+		exists, err := jwtService.cache.Exists(ctx, "blacklist:"+claims.ID)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = jwtService.cache.Exists(ctx, "blacklist:"+tokens.AccessToken)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		// Other tokens of the same session are not affected
+		other, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+		_, err = jwtService.AuthenticateToken(other.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("tokens without a jti should be blacklisted by hash", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		token, err := jwtService.generateToken(TokenTypeAccess, 1, testUsername, testRole, "", "", time.Minute)
+		require.NoError(t, err)
+
+		_, err = jwtService.AuthenticateToken(token)
+		require.NoError(t, err)
+
+		require.NoError(t, jwtService.BlacklistToken(token))
+		_, err = jwtService.AuthenticateToken(token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("the watermark should revoke tokens issued before it", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+		otherUser, err := jwtService.GenerateTokenPair(2, testUsername, testRole, device)
+		require.NoError(t, err)
+
+		require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, time.Now().Add(time.Second)))
+
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, device)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		// Other users keep their tokens
+		_, err = jwtService.AuthenticateToken(otherUser.AccessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("the watermark should not move back", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+
+		require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, time.Now().Add(time.Second)))
+		require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, time.Now().Add(-time.Hour)))
+
+		_, err = jwtService.AuthenticateToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("a later revocation stored meanwhile should not be overwritten", func(t *testing.T) {
+		now := time.Unix(time.Now().Unix(), 0)
+		for _, initial := range []time.Time{{}, now.Add(-time.Hour)} {
+			jwtService := newTestJWTService(t)
+			shared := jwtService.cache.(*MemoryCacheService)
+			if !initial.IsZero() {
+				require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, initial))
+			}
+
+			// Another instance revokes later, between reading and writing
+			jwtService.cache = &racingCache{MemoryCacheService: shared, prefix: "revoked_before:", race: func() {
+				require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, now.Add(time.Minute)))
+			}}
+			require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, now))
+
+			watermark, err := jwtService.revokedBefore(context.Background(), 1)
+			require.NoError(t, err)
+			assert.Equal(t, now.Add(time.Minute), watermark, "initial %v", initial)
+		}
+	})
+
+	t.Run("the watermark should compare whole seconds", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		token, err := jwtService.generateToken(TokenTypeAccess, 1, testUsername, testRole, "", "jti", time.Minute)
+		require.NoError(t, err)
+		claims, err := jwtService.ValidateToken(token, TokenTypeAccess)
+		require.NoError(t, err)
+		issuedAt := claims.IssuedAt.Time
+
+		// Later in the second the token was issued in
+		require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, issuedAt.Add(999*time.Millisecond)))
+		_, err = jwtService.AuthenticateToken(token)
+		assert.NoError(t, err)
+
+		require.NoError(t, jwtService.RevokeTokensIssuedBefore(1, issuedAt.Add(time.Second)))
+		_, err = jwtService.AuthenticateToken(token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("logging out everywhere should revoke outstanding access tokens only", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		before, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+
+		// Revoked in the second it was issued in, through its session
+		require.NoError(t, jwtService.RevokeAllSessions(1))
+
+		_, err = jwtService.AuthenticateToken(before.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		// Logging in again right away must work
+		after, err := jwtService.GenerateTokenPair(1, testUsername, testRole, device)
+		require.NoError(t, err)
+		_, err = jwtService.AuthenticateToken(after.AccessToken)
+		assert.NoError(t, err)
+		_, err = jwtService.RefreshToken(after.RefreshToken, device)
+		assert.NoError(t, err)
+	})
+}
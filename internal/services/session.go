@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrSessionNotFound is returned when a session does not exist or has expired
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshTokenReused is returned when a rotated-out refresh token is
+	// presented again. Its session has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Session is one logged in device. Every session has its own refresh token,
+// so logging in on a second device does not log out the first one.
+//
+// A session is also a refresh token family: every refresh rotates the token
+// and only the latest one is accepted. Presenting an older token of the
+// family means it was copied, so the whole session is revoked.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// TokenHash is the SHA-256 of the current refresh token, the token itself
+	// is never stored
+	TokenHash string `json:"token_hash"`
+}
+
+// DeviceInfo describes the client a session is created for
+type DeviceInfo struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+func sessionKey(userID uint, sessionID string) string {
+	return fmt.Sprintf("session:%d:%s", userID, sessionID)
+}
+
+// sessionsKey holds the IDs of a user's sessions, CacheService has no way to
+// list keys
+func sessionsKey(userID uint) string {
+	return fmt.Sprintf("sessions:%d", userID)
+}
+
+// newTokenID returns a random identifier for sessions and tokens
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchesToken reports whether token is the session's current refresh token
+func (s *Session) matchesToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(s.TokenHash), []byte(hashToken(token))) == 1
+}
+
+// ListSessions returns the active sessions of a user, most recently used first
+func (j *JWTService) ListSessions(userID uint) ([]Session, error) {
+	ctx := context.Background()
+
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	ids, err := j.sessionIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	live := make([]string, 0, len(ids))
+	for _, id := range ids {
+		session, err := j.loadSession(ctx, userID, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+		live = append(live, id)
+	}
+
+	// Drop the sessions that expired on their own
+	if len(live) != len(ids) {
+		if err := j.storeSessionIDs(ctx, userID, live); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(a, b int) bool {
+		return sessions[a].LastUsedAt.After(sessions[b].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession logs out a single session of a user. Its access tokens are
+// rejected from then on, as AuthenticateToken checks the session exists.
+func (j *JWTService) RevokeSession(userID uint, sessionID string) error {
+	ctx := context.Background()
+
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	if _, err := j.loadSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return j.deleteSession(ctx, userID, sessionID)
+}
+
+// deleteSession removes a session and its index entry, the caller must hold
+// sessionsMutex
+func (j *JWTService) deleteSession(ctx context.Context, userID uint, sessionID string) error {
+	if err := j.cache.Delete(ctx, sessionKey(userID, sessionID)); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	ids, err := j.sessionIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	live := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != sessionID {
+			live = append(live, id)
+		}
+	}
+	return j.storeSessionIDs(ctx, userID, live)
+}
+
+// RevokeAllSessions logs out every session of a user. The revocation
+// watermark also invalidates access tokens that are still outstanding.
+func (j *JWTService) RevokeAllSessions(userID uint) error {
+	ctx := context.Background()
+
+	if err := j.RevokeTokensIssuedBefore(userID, time.Now()); err != nil {
+		return err
+	}
+
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	ids, err := j.sessionIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := j.cache.Delete(ctx, sessionKey(userID, id)); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+	return j.cache.Delete(ctx, sessionsKey(userID))
+}
+
+func (j *JWTService) loadSession(ctx context.Context, userID uint, sessionID string) (*Session, error) {
+	session, _, err := j.loadStoredSession(ctx, userID, sessionID)
+	return session, err
+}
+
+// loadStoredSession returns the session together with its stored form, which
+// replaceSession compares against
+func (j *JWTService) loadStoredSession(ctx context.Context, userID uint, sessionID string) (*Session, string, error) {
+	stored, err := j.cache.Get(ctx, sessionKey(userID, sessionID))
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, "", ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(stored), &session); err != nil {
+		return nil, "", fmt.Errorf("failed to load session: %w", err)
+	}
+	return &session, stored, nil
+}
+
+func (j *JWTService) saveSession(ctx context.Context, session *Session) error {
+	err := j.cache.SetJSON(ctx, sessionKey(session.UserID, session.ID), session, time.Until(session.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// replaceSession stores session in place of stored, the form it was loaded
+// from. It reports false when the session changed meanwhile, e.g. because a
+// concurrent refresh rotated it on another instance. Caches without
+// CacheAtomic only get the sessionsMutex of this instance.
+func (j *JWTService) replaceSession(ctx context.Context, session *Session, stored string) (bool, error) {
+	atomic, ok := j.cache.(CacheAtomic)
+	if !ok {
+		return true, j.saveSession(ctx, session)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return false, fmt.Errorf("failed to store session: %w", err)
+	}
+	replaced, err := atomic.CompareAndSwap(ctx, sessionKey(session.UserID, session.ID), stored, string(data), time.Until(session.ExpiresAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to store session: %w", err)
+	}
+	return replaced, nil
+}
+
+// addSession records a new session ID in the user's index
+func (j *JWTService) addSession(ctx context.Context, userID uint, sessionID string) error {
+	j.sessionsMutex.Lock()
+	defer j.sessionsMutex.Unlock()
+
+	ids, err := j.sessionIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return j.storeSessionIDs(ctx, userID, append(ids, sessionID))
+}
+
+func (j *JWTService) sessionIDs(ctx context.Context, userID uint) ([]string, error) {
+	var ids []string
+	err := j.cache.GetJSON(ctx, sessionsKey(userID), &ids)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	return ids, nil
+}
+
+// storeSessionIDs replaces the user's index. It lives as long as the newest
+// possible session, so it never outlives every session it lists by much.
+func (j *JWTService) storeSessionIDs(ctx context.Context, userID uint, ids []string) error {
+	if len(ids) == 0 {
+		return j.cache.Delete(ctx, sessionsKey(userID))
+	}
+	if err := j.cache.SetJSON(ctx, sessionsKey(userID), ids, j.refreshTTL()); err != nil {
+		return fmt.Errorf("failed to store sessions: %w", err)
+	}
+	return nil
+}
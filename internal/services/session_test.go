@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"DevelopsToday/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJWTService(t *testing.T) *JWTService {
+	t.Helper()
+
+	cfg := &config.Config{
+		JWT: config.JWT{
+			Secret:          "test-secret-key-for-jwt-testing",
+			AccessTokenTTL:  900,
+			RefreshTokenTTL: 604800,
+		},
+		App: config.App{Name: "spy-cats-api"},
+	}
+
+	cache := NewMemoryCacheService()
+	t.Cleanup(func() { cache.Close() })
+	jwtService, err := NewJWTService(cfg, cache, nil)
+	require.NoError(t, err)
+	return jwtService
+}
+
+func TestJWTSessions(t *testing.T) {
+	laptop := DeviceInfo{Device: "laptop", UserAgent: "Mozilla/5.0", IP: "203.0.113.7"}
+	phone := DeviceInfo{Device: "phone", UserAgent: "SpyCats/1.0 (iOS)", IP: "198.51.100.2"}
+
+	t.Run("logging in on a second device should keep the first session", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		first, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		second, err := jwtService.GenerateTokenPair(1, testUsername, testRole, phone)
+		require.NoError(t, err)
+
+		_, err = jwtService.RefreshToken(first.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+		_, err = jwtService.RefreshToken(second.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("tokens should carry the session ID", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+
+		access, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+		refresh, err := jwtService.ValidateToken(tokens.RefreshToken, TokenTypeRefresh)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, access.SessionID)
+		assert.Equal(t, access.SessionID, refresh.SessionID)
+		assert.NotEmpty(t, refresh.ID)
+		assert.NotEqual(t, refresh.SessionID, refresh.ID)
+	})
+
+	t.Run("ListSessions should return device metadata", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		_, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, phone)
+		require.NoError(t, err)
+		_, err = jwtService.GenerateTokenPair(2, "other", testRole, laptop)
+		require.NoError(t, err)
+
+		sessions, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+
+		devices := map[string]Session{}
+		for _, session := range sessions {
+			devices[session.Device] = session
+			assert.Equal(t, uint(1), session.UserID)
+			assert.False(t, session.CreatedAt.IsZero())
+			assert.True(t, session.ExpiresAt.After(session.LastUsedAt))
+		}
+		assert.Equal(t, claims.SessionID, devices["phone"].ID)
+		assert.Equal(t, "SpyCats/1.0 (iOS)", devices["phone"].UserAgent)
+		assert.Equal(t, "203.0.113.7", devices["laptop"].IP)
+	})
+
+	t.Run("RefreshToken should keep the session and update last used", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		before, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+
+		refreshed, err := jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		require.NoError(t, err)
+
+		after, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		assert.Equal(t, before[0].ID, after[0].ID)
+		assert.False(t, after[0].LastUsedAt.Before(before[0].LastUsedAt))
+
+		claims, err := jwtService.ValidateToken(refreshed.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+		assert.Equal(t, before[0].ID, claims.SessionID)
+	})
+
+	t.Run("RevokeSession should only log out that session", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		first, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		second, err := jwtService.GenerateTokenPair(1, testUsername, testRole, phone)
+		require.NoError(t, err)
+
+		claims, err := jwtService.ValidateToken(first.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+		require.NoError(t, jwtService.RevokeSession(1, claims.SessionID))
+
+		_, err = jwtService.AuthenticateToken(first.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+		_, err = jwtService.AuthenticateToken(second.AccessToken)
+		assert.NoError(t, err)
+
+		_, err = jwtService.RefreshToken(first.RefreshToken, DeviceInfo{})
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = jwtService.RefreshToken(second.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+
+		sessions, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "phone", sessions[0].Device)
+	})
+
+	t.Run("RevokeSession should not touch sessions of other users", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		claims, err := jwtService.ValidateToken(tokens.AccessToken, TokenTypeAccess)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, jwtService.RevokeSession(2, claims.SessionID), ErrSessionNotFound)
+
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("RevokeAllSessions should log out every device", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		first, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+		second, err := jwtService.GenerateTokenPair(1, testUsername, testRole, phone)
+		require.NoError(t, err)
+
+		require.NoError(t, jwtService.RevokeAllSessions(1))
+
+		_, err = jwtService.RefreshToken(first.RefreshToken, DeviceInfo{})
+		assert.Error(t, err)
+		_, err = jwtService.RefreshToken(second.RefreshToken, DeviceInfo{})
+		assert.Error(t, err)
+
+		sessions, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("an access token should not be accepted as a refresh token", func(t *testing.T) {
+		jwtService := newTestJWTService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, laptop)
+		require.NoError(t, err)
+
+		_, err = jwtService.RefreshToken(tokens.AccessToken, DeviceInfo{})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrRefreshTokenReused)
+
+		// The session must survive a refresh attempt with the wrong token type
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestJWTRefreshRotation(t *testing.T) {
+	attacker := DeviceInfo{UserAgent: "curl/8.0", IP: "192.0.2.66"}
+
+	newService := func(t *testing.T) (*JWTService, *[]RefreshTokenReused) {
+		t.Helper()
+
+		var reused []RefreshTokenReused
+		events := NewEventBus()
+		events.Subscribe(EventRefreshTokenReused, func(_ context.Context, e Event) {
+			reused = append(reused, e.(RefreshTokenReused))
+		})
+
+		jwtService := newTestJWTService(t)
+		jwtService.events = events
+		return jwtService, &reused
+	}
+
+	t.Run("RefreshToken should rotate the refresh token", func(t *testing.T) {
+		jwtService, _ := newService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+		require.NoError(t, err)
+		rotated, err := jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+		_, err = jwtService.RefreshToken(rotated.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("reusing a rotated-out token should revoke the whole family", func(t *testing.T) {
+		jwtService, reused := newService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+		require.NoError(t, err)
+		rotated, err := jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		require.NoError(t, err)
+
+		// The stolen original token is replayed by an attacker
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, attacker)
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+		// The legitimate holder of the latest token is logged out as well
+		_, err = jwtService.RefreshToken(rotated.RefreshToken, DeviceInfo{})
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = jwtService.AuthenticateToken(rotated.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		sessions, err := jwtService.ListSessions(1)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		claims, err := jwtService.ValidateToken(tokens.RefreshToken, TokenTypeRefresh)
+		require.NoError(t, err)
+		require.Len(t, *reused, 1)
+		event := (*reused)[0]
+		assert.Equal(t, uint(1), event.UserID)
+		assert.Equal(t, claims.SessionID, event.SessionID)
+		assert.Equal(t, claims.ID, event.TokenID)
+		assert.Equal(t, "192.0.2.66", event.IP)
+		assert.Equal(t, "curl/8.0", event.UserAgent)
+		assert.False(t, event.DetectedAt.IsZero())
+	})
+
+	t.Run("reuse should not affect other sessions of the user", func(t *testing.T) {
+		jwtService, _ := newService(t)
+
+		stolen, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{Device: "laptop"})
+		require.NoError(t, err)
+		other, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{Device: "phone"})
+		require.NoError(t, err)
+
+		_, err = jwtService.RefreshToken(stolen.RefreshToken, DeviceInfo{})
+		require.NoError(t, err)
+		_, err = jwtService.RefreshToken(stolen.RefreshToken, attacker)
+		require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+		_, err = jwtService.RefreshToken(other.RefreshToken, DeviceInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("replaying a token of a revoked family should not publish again", func(t *testing.T) {
+		jwtService, reused := newService(t)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+		require.NoError(t, err)
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		require.NoError(t, err)
+
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, attacker)
+		require.ErrorIs(t, err, ErrRefreshTokenReused)
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, attacker)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		assert.Len(t, *reused, 1)
+	})
+
+	t.Run("a token rotated meanwhile on another instance should count as reused", func(t *testing.T) {
+		jwtService, reused := newService(t)
+		shared := jwtService.cache.(*MemoryCacheService)
+		other, err := NewJWTService(jwtService.cfg, shared, nil)
+		require.NoError(t, err)
+
+		tokens, err := jwtService.GenerateTokenPair(1, testUsername, testRole, DeviceInfo{})
+		require.NoError(t, err)
+
+		// The other instance rotates the token right after this one loaded
+		// the session
+		var rotated *TokenPair
+		jwtService.cache = &racingCache{MemoryCacheService: shared, prefix: "session:", race: func() {
+			rotated, err = other.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+			require.NoError(t, err)
+		}}
+
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, attacker)
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		assert.Len(t, *reused, 1)
+
+		_, err = other.RefreshToken(rotated.RefreshToken, DeviceInfo{})
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = other.AuthenticateToken(rotated.AccessToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+}
+
+// racingCache runs race once, right after the first key with prefix is read
+type racingCache struct {
+	*MemoryCacheService
+	prefix string
+	race   func()
+}
+
+func (c *racingCache) read(key string) {
+	if race := c.race; race != nil && strings.HasPrefix(key, c.prefix) {
+		c.race = nil
+		race()
+	}
+}
+
+func (c *racingCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.MemoryCacheService.Get(ctx, key)
+	c.read(key)
+	return value, err
+}
+
+func (c *racingCache) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	err := c.MemoryCacheService.GetJSON(ctx, key, dest)
+	c.read(key)
+	return err
+}
+
+func (c *racingCache) GetDelete(ctx context.Context, key string) (string, error) {
+	value, err := c.MemoryCacheService.GetDelete(ctx, key)
+	c.read(key)
+	return value, err
+}
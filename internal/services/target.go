@@ -2,7 +2,7 @@ package services
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"DevelopsToday/internal/models"
 	"DevelopsToday/internal/repo"
@@ -11,62 +11,116 @@ import (
 type TargetContext interface {
 	Add(ctx context.Context, missionID uint, t *models.Target) error
 	UpdateNotes(ctx context.Context, missionID, targetID uint, notes string) error
-	MarkComplete(ctx context.Context, targetID uint) error
+	MarkComplete(ctx context.Context, missionID, targetID uint) error
 	DeleteByID(ctx context.Context, missionID, targetID uint) error
 }
 
 type Target struct {
-	targetRepo  repo.TargetRepository
-	missionRepo repo.MissionRepository
+	store  repo.Store
+	events EventPublisher
 }
 
-func NewTarget(t repo.TargetRepository, m repo.MissionRepository) TargetContext {
+// NewTarget creates the target service. events may be nil when nobody
+// is interested in domain events.
+func NewTarget(store repo.Store, events EventPublisher) TargetContext {
+	if events == nil {
+		events = noopPublisher{}
+	}
 	return &Target{
-		targetRepo:  t,
-		missionRepo: m,
+		store:  store,
+		events: events,
 	}
 }
 
 func (s *Target) Add(ctx context.Context, missionID uint, t *models.Target) error {
-	m, err := s.missionRepo.FindByID(ctx, missionID)
-	if err != nil {
-		return err
-	}
-	if m.Complete {
-		return errors.New("cannot add target to completed mission")
-	}
-	return s.targetRepo.AddToMission(ctx, missionID, t)
+	return s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, missionID)
+		if err != nil {
+			return err
+		}
+		if m.Complete {
+			return repo.ErrMissionComplete
+		}
+		return tx.Target().AddToMission(ctx, missionID, t)
+	})
 }
 
 func (s *Target) UpdateNotes(ctx context.Context, missionID, targetID uint, notes string) error {
-	m, err := s.missionRepo.FindByID(ctx, missionID)
+	return s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, missionID)
+		if err != nil {
+			return err
+		}
+		if m.Complete {
+			return repo.ErrMissionComplete
+		}
+		if err := checkTargetOpen(m, targetID); err != nil {
+			return err
+		}
+		return tx.Target().UpdateNotes(ctx, targetID, notes)
+	})
+}
+
+func (s *Target) MarkComplete(ctx context.Context, missionID, targetID uint) error {
+	var result *repo.TargetCompletion
+	err := s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, missionID)
+		if err != nil {
+			return err
+		}
+		if !hasTarget(m, targetID) {
+			return repo.ErrTargetNotFound
+		}
+		result, err = tx.Target().MarkComplete(ctx, targetID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
-	if m.Complete {
-		return errors.New("mission is completed")
-	}
-	for _, t := range m.Targets {
-		if t.ID == targetID && t.Complete {
-			return errors.New("target is completed")
-		}
+
+	if result.MissionCompleted {
+		s.events.Publish(ctx, MissionCompleted{
+			MissionID:   result.MissionID,
+			CatID:       result.CatID,
+			CompletedAt: time.Now(),
+		})
 	}
-	return s.targetRepo.UpdateNotes(ctx, targetID, notes)
-}
 
-func (s *Target) MarkComplete(ctx context.Context, targetID uint) error {
-	return s.targetRepo.MarkComplete(ctx, targetID)
+	return nil
 }
 
 func (s *Target) DeleteByID(ctx context.Context, missionID, targetID uint) error {
-	m, err := s.missionRepo.FindByID(ctx, missionID)
-	if err != nil {
-		return err
+	return s.store.WithTx(ctx, func(tx repo.Store) error {
+		m, err := tx.Mission().FindByID(ctx, missionID)
+		if err != nil {
+			return err
+		}
+		if err := checkTargetOpen(m, targetID); err != nil {
+			return err
+		}
+		return tx.Target().DeleteByID(ctx, targetID)
+	})
+}
+
+// checkTargetOpen makes sure the target belongs to the mission and is not completed
+func checkTargetOpen(m *models.Mission, targetID uint) error {
+	for _, t := range m.Targets {
+		if t.ID == targetID {
+			if t.Complete {
+				return ErrTargetComplete
+			}
+			return nil
+		}
 	}
+	return repo.ErrTargetNotFound
+}
+
+// hasTarget reports whether the target belongs to the mission
+func hasTarget(m *models.Mission, targetID uint) bool {
 	for _, t := range m.Targets {
-		if t.ID == targetID && t.Complete {
-			return errors.New("cannot delete completed target")
+		if t.ID == targetID {
+			return true
 		}
 	}
-	return s.targetRepo.DeleteByID(ctx, targetID)
+	return false
 }
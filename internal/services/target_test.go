@@ -2,17 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
 	"DevelopsToday/internal/repo/mocks"
-
-	"gorm.io/gorm"
 )
 
 func TestTargetService(t *testing.T) {
 	store := mocks.NewRepository()
-	targetService := NewTarget(store.Target(), store.Mission())
+	targetService := NewTarget(store, nil)
 	ctx := context.Background()
 
 	t.Run("Add should add target to existing mission", func(t *testing.T) {
@@ -33,7 +33,7 @@ func TestTargetService(t *testing.T) {
 		}
 
 		// Verify target was added to mission
-		missionService := NewMission(store.Mission())
+		missionService := NewMission(store)
 		mission, err := missionService.GetByID(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -60,8 +60,8 @@ func TestTargetService(t *testing.T) {
 		}
 
 		err := targetService.Add(ctx, 999, target)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
@@ -77,8 +77,8 @@ func TestTargetService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for completed mission")
 		}
-		if err.Error() != "cannot add target to completed mission" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, repo.ErrMissionComplete) {
+			t.Fatalf("Expected repo.ErrMissionComplete, got %v", err)
 		}
 	})
 
@@ -89,7 +89,7 @@ func TestTargetService(t *testing.T) {
 		}
 
 		// Verify notes were updated
-		missionService := NewMission(store.Mission())
+		missionService := NewMission(store)
 		mission, err := missionService.GetByID(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -109,8 +109,8 @@ func TestTargetService(t *testing.T) {
 
 	t.Run("UpdateNotes should fail for non-existing mission", func(t *testing.T) {
 		err := targetService.UpdateNotes(ctx, 999, 1, "Test notes")
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
@@ -119,8 +119,8 @@ func TestTargetService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for completed mission")
 		}
-		if err.Error() != "mission is completed" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, repo.ErrMissionComplete) {
+			t.Fatalf("Expected repo.ErrMissionComplete, got %v", err)
 		}
 	})
 
@@ -133,13 +133,13 @@ func TestTargetService(t *testing.T) {
 	})
 
 	t.Run("MarkComplete should mark target as complete", func(t *testing.T) {
-		err := targetService.MarkComplete(ctx, 1)
+		err := targetService.MarkComplete(ctx, 1, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
 		// Verify target was marked complete
-		missionService := NewMission(store.Mission())
+		missionService := NewMission(store)
 		mission, err := missionService.GetByID(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -158,9 +158,16 @@ func TestTargetService(t *testing.T) {
 	})
 
 	t.Run("MarkComplete should return error for non-existing target", func(t *testing.T) {
-		err := targetService.MarkComplete(ctx, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		err := targetService.MarkComplete(ctx, 1, 999)
+		if !errors.Is(err, repo.ErrTargetNotFound) {
+			t.Fatalf("Expected repo.ErrTargetNotFound, got %v", err)
+		}
+	})
+
+	t.Run("MarkComplete should reject a target of another mission", func(t *testing.T) {
+		err := targetService.MarkComplete(ctx, 1, 7) // Target 7 belongs to mission 4
+		if !errors.Is(err, repo.ErrTargetNotFound) {
+			t.Fatalf("Expected repo.ErrTargetNotFound, got %v", err)
 		}
 	})
 
@@ -169,8 +176,8 @@ func TestTargetService(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for completed target")
 		}
-		if err.Error() != "cannot delete completed target" {
-			t.Fatalf("Expected specific error message, got %v", err)
+		if !errors.Is(err, ErrTargetComplete) {
+			t.Fatalf("Expected ErrTargetComplete, got %v", err)
 		}
 	})
 
@@ -194,7 +201,7 @@ func TestTargetService(t *testing.T) {
 		}
 
 		// Verify target was removed from mission
-		missionService := NewMission(store.Mission())
+		missionService := NewMission(store)
 		mission, err := missionService.GetByID(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -214,15 +221,81 @@ func TestTargetService(t *testing.T) {
 
 	t.Run("DeleteByID should return error for non-existing mission", func(t *testing.T) {
 		err := targetService.DeleteByID(ctx, 999, 1)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrMissionNotFound) {
+			t.Fatalf("Expected repo.ErrMissionNotFound, got %v", err)
 		}
 	})
 
 	t.Run("DeleteByID should return error for non-existing target", func(t *testing.T) {
 		err := targetService.DeleteByID(ctx, 1, 999)
-		if err != gorm.ErrRecordNotFound {
-			t.Fatalf("Expected gorm.ErrRecordNotFound, got %v", err)
+		if !errors.Is(err, repo.ErrTargetNotFound) {
+			t.Fatalf("Expected repo.ErrTargetNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTargetService_AutoComplete(t *testing.T) {
+	store := mocks.NewRepository()
+	events := NewEventBus()
+	targetService := NewTarget(store, events)
+	missionService := NewMission(store)
+	ctx := context.Background()
+
+	var published []MissionCompleted
+	events.Subscribe(EventMissionCompleted, func(_ context.Context, e Event) {
+		published = append(published, e.(MissionCompleted))
+	})
+
+	autoMission := &models.Mission{
+		AutoComplete: true,
+		Targets: []models.Target{
+			{Name: "Auto 1", Country: "Spain"},
+			{Name: "Auto 2", Country: "Spain"},
+		},
+	}
+	if err := missionService.Create(ctx, autoMission); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("MarkComplete should keep mission open while targets remain", func(t *testing.T) {
+		if err := targetService.MarkComplete(ctx, autoMission.ID, autoMission.Targets[0].ID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		mission, _ := missionService.GetByID(ctx, autoMission.ID)
+		if mission.Complete {
+			t.Fatal("Expected mission to stay open")
+		}
+		if len(published) != 0 {
+			t.Fatalf("Expected no events, got %d", len(published))
+		}
+	})
+
+	t.Run("MarkComplete should complete mission with its last target", func(t *testing.T) {
+		if err := targetService.MarkComplete(ctx, autoMission.ID, autoMission.Targets[1].ID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		mission, _ := missionService.GetByID(ctx, autoMission.ID)
+		if !mission.Complete {
+			t.Fatal("Expected mission to be completed automatically")
+		}
+		if len(published) != 1 || published[0].MissionID != autoMission.ID {
+			t.Fatalf("Expected one MissionCompleted event, got %v", published)
+		}
+	})
+
+	t.Run("MarkComplete should not complete missions without auto_complete", func(t *testing.T) {
+		if err := targetService.MarkComplete(ctx, 4, 7); err != nil { // Mission 4 has a single target
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		mission, _ := missionService.GetByID(ctx, 4)
+		if mission.Complete {
+			t.Fatal("Expected mission without auto_complete to stay open")
+		}
+		if len(published) != 1 {
+			t.Fatalf("Expected no new events, got %d", len(published))
 		}
 	})
 }
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrTaskQueueFull is returned when a TaskQueue has no room for another task
+	ErrTaskQueueFull = errors.New("task queue is full")
+	// ErrTaskQueueClosed is returned for tasks submitted after Close
+	ErrTaskQueueClosed = errors.New("task queue is closed")
+)
+
+// TaskQueue defaults for settings left at zero
+const (
+	defaultTaskQueueSize    = 100
+	defaultTaskQueueWorkers = 2
+)
+
+// Task is work run in the background. Its context is canceled when the queue
+// is closed and the queued tasks do not finish in time.
+type Task func(ctx context.Context)
+
+// TaskQueue runs tasks in the background on a fixed number of workers, so
+// that slow work such as sending emails does not hold up responses. At most
+// size tasks wait, further ones are rejected. Close runs the waiting ones
+// before returning.
+type TaskQueue struct {
+	tasks  chan Task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// mutex keeps Submit from sending on the channel Close closes
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewTaskQueue starts workers that run up to size queued tasks
+func NewTaskQueue(size, workers int) *TaskQueue {
+	if size <= 0 {
+		size = defaultTaskQueueSize
+	}
+	if workers <= 0 {
+		workers = defaultTaskQueueWorkers
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &TaskQueue{
+		tasks:  make(chan Task, size),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+func (q *TaskQueue) work() {
+	defer q.wg.Done()
+	for task := range q.tasks {
+		task(q.ctx)
+	}
+}
+
+// Submit queues task. It returns ErrTaskQueueFull instead of waiting for room.
+func (q *TaskQueue) Submit(task Task) error {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		return ErrTaskQueueClosed
+	}
+	select {
+	case q.tasks <- task:
+		return nil
+	default:
+		return ErrTaskQueueFull
+	}
+}
+
+// Close stops accepting tasks and waits until the queued ones have run. When
+// ctx ends first, the context of the remaining tasks is canceled and Close
+// returns the error of ctx.
+func (q *TaskQueue) Close(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.tasks)
+	}
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	defer q.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
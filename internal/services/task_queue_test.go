@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskQueue(t *testing.T) {
+	t.Run("Close should wait for the queued tasks to run", func(t *testing.T) {
+		queue := NewTaskQueue(10, 1)
+		var ran atomic.Int32
+		for i := 0; i < 10; i++ {
+			require.NoError(t, queue.Submit(func(ctx context.Context) {
+				time.Sleep(time.Millisecond)
+				ran.Add(1)
+			}))
+		}
+
+		require.NoError(t, queue.Close(context.Background()))
+		assert.Equal(t, int32(10), ran.Load())
+	})
+
+	t.Run("Submit should fail rather than wait when the queue is full", func(t *testing.T) {
+		queue := NewTaskQueue(1, 1)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		require.NoError(t, queue.Submit(func(ctx context.Context) {
+			close(started)
+			<-release
+		}))
+		<-started
+
+		require.NoError(t, queue.Submit(func(ctx context.Context) {}))
+		assert.ErrorIs(t, queue.Submit(func(ctx context.Context) {}), ErrTaskQueueFull)
+
+		close(release)
+		require.NoError(t, queue.Close(context.Background()))
+	})
+
+	t.Run("Submit should fail after Close", func(t *testing.T) {
+		queue := NewTaskQueue(1, 1)
+		require.NoError(t, queue.Close(context.Background()))
+
+		assert.ErrorIs(t, queue.Submit(func(ctx context.Context) {}), ErrTaskQueueClosed)
+		assert.NoError(t, queue.Close(context.Background()), "closing twice should be harmless")
+	})
+
+	t.Run("Close should cancel the tasks still running when its context ends", func(t *testing.T) {
+		queue := NewTaskQueue(1, 1)
+		canceled := make(chan struct{})
+		require.NoError(t, queue.Submit(func(ctx context.Context) {
+			<-ctx.Done()
+			close(canceled)
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, queue.Close(ctx), context.DeadlineExceeded)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the running task was not canceled")
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TieredCacheOptions configures TieredCacheService
+type TieredCacheOptions struct {
+	// LocalTTL caps how long a value stays in the in-process L1 cache
+	LocalTTL time.Duration
+	// NegativeTTL is how long a missing key is remembered in L1, 0 disables it
+	NegativeTTL time.Duration
+	// Channel is the Redis pub/sub channel used for L1 invalidation
+	Channel string
+	// Local bounds the L1 cache
+	Local MemoryCacheOptions
+}
+
+// tieredMiss marks a key known to be missing from L2
+type tieredMiss struct{}
+
+// tieredInvalidation is published on every write so that other instances
+// drop the key from their L1
+type tieredInvalidation struct {
+	Origin string `json:"origin"`
+	Key    string `json:"key"`
+}
+
+// TieredCacheService implements CacheService with an in-process
+// MemoryCacheService (L1) in front of Redis (L2).
+//
+// Reads are served from L1 when possible. Writes go to Redis and publish an
+// invalidation message, so every instance drops the key from its L1. Misses
+// are cached in L1 for NegativeTTL, which keeps hot "not blacklisted" checks
+// off Redis. A read that an invalidation overtakes does not fill L1, see
+// tieredGenerations. If an invalidation message is lost, an instance may
+// serve a stale value for at most LocalTTL (NegativeTTL for misses).
+type TieredCacheService struct {
+	local       *MemoryCacheService
+	generations tieredGenerations
+	remote      CacheService
+	client      *redis.Client
+	pubsub      *redis.PubSub
+	opts        TieredCacheOptions
+	instanceID  string
+	stopped     chan struct{}
+}
+
+// tieredGenerationSlots is the number of generation counters keys share
+const tieredGenerationSlots = 256
+
+// tieredGenerations counts the invalidations of keys. A read takes the
+// generation of its key before going to Redis and fills L1 only if it is
+// unchanged afterwards; otherwise the key was written meanwhile and what the
+// read returned may already be stale. Keys are hashed onto a fixed number
+// of slots, keys sharing a slot merely skip some fills.
+type tieredGenerations struct {
+	slots [tieredGenerationSlots]struct {
+		mutex      sync.Mutex
+		generation uint64
+	}
+}
+
+// current returns the generation of key
+func (g *tieredGenerations) current(key string) uint64 {
+	slot := &g.slots[fnv1a(key)%tieredGenerationSlots]
+	slot.mutex.Lock()
+	defer slot.mutex.Unlock()
+	return slot.generation
+}
+
+// invalidate starts a new generation of key and runs drop in it
+func (g *tieredGenerations) invalidate(key string, drop func()) {
+	slot := &g.slots[fnv1a(key)%tieredGenerationSlots]
+	slot.mutex.Lock()
+	defer slot.mutex.Unlock()
+	slot.generation++
+	drop()
+}
+
+// fill runs store unless key was invalidated since generation
+func (g *tieredGenerations) fill(key string, generation uint64, store func()) {
+	slot := &g.slots[fnv1a(key)%tieredGenerationSlots]
+	slot.mutex.Lock()
+	defer slot.mutex.Unlock()
+	if slot.generation == generation {
+		store()
+	}
+}
+
+// NewTieredCacheService creates a tiered cache on top of client and subscribes
+// to the invalidation channel
+func NewTieredCacheService(ctx context.Context, client *redis.Client, opts TieredCacheOptions) (*TieredCacheService, error) {
+	if opts.LocalTTL <= 0 {
+		return nil, fmt.Errorf("tiered cache local TTL must be positive")
+	}
+
+	instanceID, err := newInstanceID()
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := NewMemoryCacheServiceWithOptions(opts.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	pubsub := client.Subscribe(ctx, opts.Channel)
+	// Wait for the subscription so no invalidation is missed after returning
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		_ = local.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", opts.Channel, err)
+	}
+
+	t := &TieredCacheService{
+		local:      local,
+		remote:     NewRedisCacheService(client),
+		client:     client,
+		pubsub:     pubsub,
+		opts:       opts,
+		instanceID: instanceID,
+		stopped:    make(chan struct{}),
+	}
+
+	go t.listen()
+
+	return t, nil
+}
+
+func newInstanceID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate cache instance id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// listen drops keys invalidated by other instances from L1
+func (t *TieredCacheService) listen() {
+	defer close(t.stopped)
+
+	for msg := range t.pubsub.Channel() {
+		var inv tieredInvalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Origin == t.instanceID {
+			continue
+		}
+		t.drop(inv.Key)
+	}
+}
+
+// drop removes key from L1 and keeps reads already on their way to Redis
+// from putting it back
+func (t *TieredCacheService) drop(key string) {
+	t.generations.invalidate(key, func() {
+		_ = t.local.Delete(context.Background(), key)
+	})
+}
+
+// invalidate drops key from L1 here and on every other instance
+func (t *TieredCacheService) invalidate(ctx context.Context, key string) error {
+	t.drop(key)
+
+	payload, err := json.Marshal(tieredInvalidation{Origin: t.instanceID, Key: key})
+	if err != nil {
+		return err
+	}
+	return t.client.Publish(ctx, t.opts.Channel, payload).Err()
+}
+
+// Set stores a key-value pair with optional TTL
+func (t *TieredCacheService) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	// L1 is filled by the next read, so it always holds Redis' representation
+	return t.invalidate(ctx, key)
+}
+
+// Get retrieves a value by key
+func (t *TieredCacheService) Get(ctx context.Context, key string) (string, error) {
+	if item, ok := t.local.lookup(key); ok {
+		if _, miss := item.Value.(tieredMiss); miss {
+			return "", ErrCacheMiss
+		}
+		return item.Value.(string), nil
+	}
+
+	return t.load(ctx, key)
+}
+
+// load reads key from Redis and stores the result, hit or miss, in L1
+func (t *TieredCacheService) load(ctx context.Context, key string) (string, error) {
+	generation := t.generations.current(key)
+
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+
+	value, getErr := get.Result()
+	if errors.Is(getErr, redis.Nil) {
+		if t.opts.NegativeTTL > 0 {
+			t.generations.fill(key, generation, func() {
+				_ = t.local.Set(ctx, key, tieredMiss{}, t.opts.NegativeTTL)
+			})
+		}
+		return "", ErrCacheMiss
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Never keep a value in L1 longer than Redis does
+	ttl := t.opts.LocalTTL
+	if remaining := pttl.Val(); remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	t.generations.fill(key, generation, func() {
+		_ = t.local.Set(ctx, key, value, ttl)
+	})
+
+	return value, nil
+}
+
+// Delete removes a key from cache
+func (t *TieredCacheService) Delete(ctx context.Context, key string) error {
+	if err := t.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Exists checks if a key exists in cache
+func (t *TieredCacheService) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := t.Get(ctx, key)
+	if errors.Is(err, ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON stores a JSON-serializable object
+func (t *TieredCacheService) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return t.Set(ctx, key, string(data), ttl)
+}
+
+// GetJSON retrieves and unmarshals a JSON object
+func (t *TieredCacheService) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, err := t.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal([]byte(data), dest)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return nil
+}
+
+// Increment adds one to the counter at key. Counters live in Redis only, a
+// copy in L1 would be stale after the next increment on another instance.
+func (t *TieredCacheService) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrementRedis(ctx, t.client, key, ttl)
+}
+
+// Counter returns the value of the counter at key, read from Redis
+func (t *TieredCacheService) Counter(ctx context.Context, key string) (int64, error) {
+	return redisCounter(ctx, t.client, key)
+}
+
+// CompareAndSwap replaces the value at key when it equals old. The value
+// in L1 may be stale, so the comparison is made in Redis.
+func (t *TieredCacheService) CompareAndSwap(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	swapped, err := compareAndSwapRedis(ctx, t.client, key, old, value, ttl)
+	if err != nil || !swapped {
+		return false, err
+	}
+	return true, t.invalidate(ctx, key)
+}
+
+// SetIfAbsent stores value when key does not exist in Redis
+func (t *TieredCacheService) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	stored, err := setIfAbsentRedis(ctx, t.client, key, value, ttl)
+	if err != nil || !stored {
+		return false, err
+	}
+	// Drops a cached miss
+	return true, t.invalidate(ctx, key)
+}
+
+// GetDelete returns and removes the value at key. It always goes to Redis,
+// L1 would hand the value out once per instance.
+func (t *TieredCacheService) GetDelete(ctx context.Context, key string) (string, error) {
+	value, err := getDeleteRedis(ctx, t.client, key)
+	if err != nil {
+		return "", err
+	}
+	return value, t.invalidate(ctx, key)
+}
+
+// Ping checks if the cache service is available
+func (t *TieredCacheService) Ping(ctx context.Context) error {
+	return t.remote.Ping(ctx)
+}
+
+// Close stops the invalidation listener and closes both tiers
+func (t *TieredCacheService) Close() error {
+	err := t.pubsub.Close()
+	<-t.stopped
+
+	_ = t.local.Close()
+	if closeErr := t.remote.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
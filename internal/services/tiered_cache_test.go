@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTieredCache creates a tiered cache on mr whose L1 runs on clock
+func newTestTieredCache(t *testing.T, mr *miniredis.Miniredis, clock *testClock) *TieredCacheService {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache, err := NewTieredCacheService(context.Background(), client, TieredCacheOptions{
+		LocalTTL:    30 * time.Second,
+		NegativeTTL: 5 * time.Second,
+		Channel:     "cache:invalidate",
+	})
+	require.NoError(t, err)
+
+	cache.local.now = clock.Now
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestTieredCacheService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Get should be served from L1 after the first read", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newTestTieredCache(t, mr, newTestClock())
+
+		require.NoError(t, cache.Set(ctx, "tiered:hit", "value", time.Minute))
+		_, err := cache.Get(ctx, "tiered:hit")
+		require.NoError(t, err)
+
+		commands := mr.CommandCount()
+		for i := 0; i < 10; i++ {
+			result, err := cache.Get(ctx, "tiered:hit")
+			require.NoError(t, err)
+			assert.Equal(t, "value", result)
+		}
+		assert.Equal(t, commands, mr.CommandCount(), "L1 hits must not reach Redis")
+	})
+
+	t.Run("Exists misses should be cached for NegativeTTL", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		clock := newTestClock()
+		cache := newTestTieredCache(t, mr, clock)
+
+		exists, err := cache.Exists(ctx, "blacklist:token")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		commands := mr.CommandCount()
+		for i := 0; i < 10; i++ {
+			exists, err = cache.Exists(ctx, "blacklist:token")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		}
+		assert.Equal(t, commands, mr.CommandCount(), "cached misses must not reach Redis")
+
+		// Another writer adds the key behind our back; the miss expires
+		require.NoError(t, mr.Set("blacklist:token", "1"))
+		clock.Advance(6 * time.Second)
+
+		exists, err = cache.Exists(ctx, "blacklist:token")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Set should replace a cached miss", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newTestTieredCache(t, mr, newTestClock())
+
+		_, err := cache.Get(ctx, "tiered:miss")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+
+		require.NoError(t, cache.Set(ctx, "tiered:miss", "value", time.Minute))
+
+		result, err := cache.Get(ctx, "tiered:miss")
+		require.NoError(t, err)
+		assert.Equal(t, "value", result)
+	})
+
+	t.Run("L1 should not outlive the Redis TTL", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		clock := newTestClock()
+		cache := newTestTieredCache(t, mr, clock)
+
+		require.NoError(t, cache.Set(ctx, "tiered:short", "value", 2*time.Second))
+		_, err := cache.Get(ctx, "tiered:short")
+		require.NoError(t, err)
+
+		clock.Advance(3 * time.Second)
+		mr.FastForward(3 * time.Second)
+
+		_, err = cache.Get(ctx, "tiered:short")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("writes should invalidate L1 on other instances", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		first := newTestTieredCache(t, mr, newTestClock())
+		second := newTestTieredCache(t, mr, newTestClock())
+
+		require.NoError(t, first.Set(ctx, "tiered:shared", "v1", time.Minute))
+		result, err := second.Get(ctx, "tiered:shared")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", result)
+
+		// second now holds the key in L1 and a miss for the blacklist key
+		exists, err := second.Exists(ctx, "blacklist:shared")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		require.NoError(t, first.Set(ctx, "tiered:shared", "v2", time.Minute))
+		require.NoError(t, first.Set(ctx, "blacklist:shared", "1", time.Minute))
+
+		assert.Eventually(t, func() bool {
+			result, err := second.Get(ctx, "tiered:shared")
+			return err == nil && result == "v2"
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			exists, err := second.Exists(ctx, "blacklist:shared")
+			return err == nil && exists
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, first.Delete(ctx, "tiered:shared"))
+		assert.Eventually(t, func() bool {
+			_, err := second.Get(ctx, "tiered:shared")
+			return err == ErrCacheMiss
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("a read overtaken by an invalidation should not fill L1", func(t *testing.T) {
+		for name, before := range map[string]*string{"miss": nil, "hit": ptr("old")} {
+			mr := miniredis.RunT(t)
+			cache := newTestTieredCache(t, mr, newTestClock())
+			if before != nil {
+				require.NoError(t, mr.Set("blacklist:raced", *before))
+			}
+
+			// Another instance writes the key right after this read reached Redis
+			cache.client.AddHook(&afterPipelineHook{after: func() {
+				require.NoError(t, mr.Set("blacklist:raced", "new"))
+				cache.drop("blacklist:raced")
+			}})
+
+			_, err := cache.Get(ctx, "blacklist:raced")
+			if before == nil {
+				assert.ErrorIs(t, err, ErrCacheMiss, name)
+			} else {
+				require.NoError(t, err, name)
+			}
+
+			result, err := cache.Get(ctx, "blacklist:raced")
+			require.NoError(t, err, name)
+			assert.Equal(t, "new", result, name)
+		}
+	})
+}
+
+// afterPipelineHook runs after once, when the first pipeline reading a key
+// has been executed
+type afterPipelineHook struct {
+	after func()
+	once  sync.Once
+}
+
+func (h *afterPipelineHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *afterPipelineHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (h *afterPipelineHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		if len(cmds) > 0 && cmds[0].Name() == "get" {
+			h.once.Do(h.after)
+		}
+		return err
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestCacheFactoryTiered(t *testing.T) {
+	factory := NewCacheFactory()
+
+	t.Run("CreateCacheService with tiered type should connect to Redis", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cfg := &config.Config{
+			Redis: config.Redis{URL: "redis://" + mr.Addr()},
+			Cache: config.Cache{
+				LocalTTL:            time.Minute,
+				NegativeTTL:         time.Second,
+				InvalidationChannel: "cache:invalidate",
+			},
+		}
+
+		cache, err := factory.CreateCacheService(CacheTypeTiered, cfg)
+		require.NoError(t, err)
+		assert.IsType(t, &TieredCacheService{}, cache)
+		cache.Close()
+	})
+
+	t.Run("CreateCacheService with tiered type should fail without Redis", func(t *testing.T) {
+		cfg := &config.Config{
+			Redis: config.Redis{URL: "redis://127.0.0.1:1"},
+			Cache: config.Cache{LocalTTL: time.Minute},
+		}
+
+		cache, err := factory.CreateCacheService(CacheTypeTiered, cfg)
+		assert.Error(t, err)
+		assert.Nil(t, cache)
+	})
+}
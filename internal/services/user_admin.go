@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+)
+
+// Audit trail actions
+const (
+	AuditUserRoleChanged   = "user.role_changed"
+	AuditUserDeleted       = "user.deleted"
+	AuditUserTokensRevoked = "user.tokens_revoked"
+	AuditUserLoginUnlocked = "user.login_unlocked"
+	AuditInvitationCreated = "invitation.created"
+)
+
+var (
+	// ErrUnknownRole is returned when assigning a role that is not defined
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrSelfModification is returned when admins change their own role or
+	// delete themselves, which could lock every admin out
+	ErrSelfModification = errors.New("cannot modify own account")
+)
+
+// Actor is the user performing an administrative action
+type Actor struct {
+	UserID uint
+	IP     string
+}
+
+// UserAdminService manages user accounts. Every change is recorded in the
+// audit trail.
+type UserAdminService struct {
+	store       repo.Store
+	rbac        *RBAC
+	jwt         *JWTService
+	invitations *InvitationService
+	loginGuard  *LoginGuard
+}
+
+func NewUserAdminService(store repo.Store, rbac *RBAC, jwtService *JWTService, invitations *InvitationService, loginGuard *LoginGuard) *UserAdminService {
+	return &UserAdminService{
+		store:       store,
+		rbac:        rbac,
+		jwt:         jwtService,
+		invitations: invitations,
+		loginGuard:  loginGuard,
+	}
+}
+
+func (s *UserAdminService) List(ctx context.Context, page repo.Pagination) ([]models.User, int64, error) {
+	return s.store.User().FindPage(ctx, page)
+}
+
+func (s *UserAdminService) Get(ctx context.Context, id uint) (*models.User, error) {
+	return s.store.User().FindByID(ctx, id)
+}
+
+// ChangeRole assigns a new role. Tokens carry the role, so the user is
+// logged out everywhere and gets the new role on the next login.
+func (s *UserAdminService) ChangeRole(ctx context.Context, actor Actor, id uint, role string) (*models.User, error) {
+	if !s.rbac.HasRole(role) {
+		return nil, ErrUnknownRole
+	}
+	if actor.UserID == id {
+		return nil, ErrSelfModification
+	}
+
+	var user *models.User
+	var changed bool
+	err := s.store.WithTx(ctx, func(tx repo.Store) error {
+		var err error
+		user, err = tx.User().FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user.Role == role {
+			return nil
+		}
+
+		previous := user.Role
+		user.Role = role
+		if err := tx.User().Update(ctx, user); err != nil {
+			return err
+		}
+		changed = true
+		return record(ctx, tx, actor, AuditUserRoleChanged, &id, fmt.Sprintf("role %s -> %s", previous, role))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		if err := s.jwt.RevokeAllSessions(id); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// Delete removes a user and revokes their tokens
+func (s *UserAdminService) Delete(ctx context.Context, actor Actor, id uint) error {
+	if actor.UserID == id {
+		return ErrSelfModification
+	}
+
+	err := s.store.WithTx(ctx, func(tx repo.Store) error {
+		user, err := tx.User().FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.User().DeleteByID(ctx, id); err != nil {
+			return err
+		}
+		return record(ctx, tx, actor, AuditUserDeleted, &id, fmt.Sprintf("username %s", user.Username))
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.jwt.RevokeAllSessions(id)
+}
+
+// RevokeTokens logs a user out of every device, e.g. when a device is
+// compromised
+func (s *UserAdminService) RevokeTokens(ctx context.Context, actor Actor, id uint) error {
+	if _, err := s.store.User().FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.jwt.RevokeAllSessions(id); err != nil {
+		return err
+	}
+	return record(ctx, s.store, actor, AuditUserTokensRevoked, &id, "")
+}
+
+// UnlockLogin lifts the lockout of a user after too many failed logins and,
+// unless ip is empty, the lockout of the client IP they log in from
+func (s *UserAdminService) UnlockLogin(ctx context.Context, actor Actor, id uint, ip string) error {
+	user, err := s.store.User().FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.loginGuard.Unlock(ctx, user.Username, ip); err != nil {
+		return err
+	}
+
+	var details string
+	if ip != "" {
+		details = fmt.Sprintf("ip %s", ip)
+	}
+	return record(ctx, s.store, actor, AuditUserLoginUnlocked, &id, details)
+}
+
+// Invite issues an invitation code for registration
+func (s *UserAdminService) Invite(ctx context.Context, actor Actor) (*Invitation, error) {
+	invitation, err := s.invitations.Create(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("expires %s", invitation.ExpiresAt.UTC().Format(time.RFC3339))
+	if err := record(ctx, s.store, actor, AuditInvitationCreated, nil, details); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// AuditLog returns the audit trail, newest first
+func (s *UserAdminService) AuditLog(ctx context.Context, filter repo.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	return s.store.AuditLog().FindPage(ctx, filter)
+}
+
+func record(ctx context.Context, store repo.Store, actor Actor, action string, subjectID *uint, details string) error {
+	entry := &models.AuditLog{
+		ActorID:   actor.UserID,
+		Action:    action,
+		SubjectID: subjectID,
+		Details:   details,
+		IP:        actor.IP,
+	}
+	if err := store.AuditLog().Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"DevelopsToday/config"
+	"DevelopsToday/internal/models"
+	"DevelopsToday/internal/repo"
+	"DevelopsToday/internal/repo/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInvitationService(t *testing.T, mode string) *InvitationService {
+	t.Helper()
+
+	rbac, err := NewRBAC(config.RBAC{})
+	require.NoError(t, err)
+
+	cache := NewMemoryCacheService()
+	t.Cleanup(func() { cache.Close() })
+	invitations, err := NewInvitationService(config.Registration{Mode: mode, InviteTTL: time.Hour}, cache, rbac)
+	require.NoError(t, err)
+	return invitations
+}
+
+func TestInvitationService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("open registration should always get the default role", func(t *testing.T) {
+		invitations := newTestInvitationService(t, RegistrationOpen)
+
+		admission, err := invitations.Admit(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, "agent", admission.Role)
+		// Nothing to put back
+		assert.NoError(t, invitations.Release(ctx, admission))
+	})
+
+	t.Run("invite mode should require a valid code", func(t *testing.T) {
+		invitations := newTestInvitationService(t, RegistrationInvite)
+
+		_, err := invitations.Admit(ctx, "")
+		assert.ErrorIs(t, err, ErrInvitationRequired)
+		_, err = invitations.Admit(ctx, "made-up-code")
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("an invitation code should be single-use", func(t *testing.T) {
+		invitations := newTestInvitationService(t, RegistrationInvite)
+
+		invitation, err := invitations.Create(ctx, 1)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), invitation.ExpiresAt, time.Minute)
+
+		admission, err := invitations.Admit(ctx, invitation.Code)
+		require.NoError(t, err)
+		assert.Equal(t, "agent", admission.Role)
+
+		_, err = invitations.Admit(ctx, invitation.Code)
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("a released invitation should be usable once more", func(t *testing.T) {
+		invitations := newTestInvitationService(t, RegistrationInvite)
+		invitation, err := invitations.Create(ctx, 1)
+		require.NoError(t, err)
+
+		admission, err := invitations.Admit(ctx, invitation.Code)
+		require.NoError(t, err)
+		require.NoError(t, invitations.Release(ctx, admission))
+
+		_, err = invitations.Admit(ctx, invitation.Code)
+		require.NoError(t, err)
+		_, err = invitations.Admit(ctx, invitation.Code)
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("a code redeemed meanwhile on another instance should admit only once", func(t *testing.T) {
+		invitations := newTestInvitationService(t, RegistrationInvite)
+		invitation, err := invitations.Create(ctx, 1)
+		require.NoError(t, err)
+
+		// The other instance shares only the cache
+		other, err := NewInvitationService(invitations.cfg, invitations.cache, invitations.rbac)
+		require.NoError(t, err)
+		var raced error
+		invitations.cache = &racingCache{MemoryCacheService: invitations.cache.(*MemoryCacheService), prefix: "invitation:", race: func() {
+			_, raced = other.Admit(ctx, invitation.Code)
+		}}
+
+		_, err = invitations.Admit(ctx, invitation.Code)
+		require.NoError(t, err)
+		assert.ErrorIs(t, raced, ErrInvalidInvitation)
+	})
+
+	t.Run("unknown registration modes should be rejected", func(t *testing.T) {
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+
+		_, err = NewInvitationService(config.Registration{Mode: "closed"}, NewMemoryCacheService(), rbac)
+		assert.Error(t, err)
+	})
+}
+
+func TestUserAdminService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*UserAdminService, *JWTService, *models.User) {
+		store := mocks.NewRepository()
+		jwtService := newTestJWTService(t)
+		rbac, err := NewRBAC(config.RBAC{})
+		require.NoError(t, err)
+
+		user := &models.User{Username: "whiskers", Email: "whiskers@spycats.com", Password: "secret", Role: "agent"}
+		require.NoError(t, store.User().Create(ctx, user))
+
+		return NewUserAdminService(store, rbac, jwtService, newTestInvitationService(t, RegistrationInvite), NewLoginGuard(config.Login{}, jwtService.cache, nil)), jwtService, user
+	}
+	admin := Actor{UserID: 100, IP: "203.0.113.7"}
+
+	t.Run("ChangeRole should update the role, record it and revoke tokens", func(t *testing.T) {
+		users, jwtService, user := setup(t)
+
+		tokens, err := jwtService.GenerateTokenPair(user.ID, user.Username, user.Role, DeviceInfo{})
+		require.NoError(t, err)
+
+		updated, err := users.ChangeRole(ctx, admin, user.ID, "manager")
+		require.NoError(t, err)
+		assert.Equal(t, "manager", updated.Role)
+
+		// The old token still says "agent"
+		_, err = jwtService.RefreshToken(tokens.RefreshToken, DeviceInfo{})
+		assert.Error(t, err)
+
+		entries, total, err := users.AuditLog(ctx, repo.AuditLogFilter{SubjectID: &user.ID})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), total)
+		assert.Equal(t, AuditUserRoleChanged, entries[0].Action)
+		assert.Equal(t, admin.UserID, entries[0].ActorID)
+		assert.Equal(t, "203.0.113.7", entries[0].IP)
+		assert.Equal(t, "role agent -> manager", entries[0].Details)
+	})
+
+	t.Run("ChangeRole should reject unknown roles and self-promotion", func(t *testing.T) {
+		users, _, user := setup(t)
+
+		_, err := users.ChangeRole(ctx, admin, user.ID, "superuser")
+		assert.ErrorIs(t, err, ErrUnknownRole)
+
+		_, err = users.ChangeRole(ctx, Actor{UserID: user.ID}, user.ID, "admin")
+		assert.ErrorIs(t, err, ErrSelfModification)
+
+		_, err = users.ChangeRole(ctx, admin, 999, "manager")
+		assert.ErrorIs(t, err, repo.ErrUserNotFound)
+
+		_, total, err := users.AuditLog(ctx, repo.AuditLogFilter{})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Delete should remove the user and record it", func(t *testing.T) {
+		users, _, user := setup(t)
+
+		require.NoError(t, users.Delete(ctx, admin, user.ID))
+
+		_, err := users.Get(ctx, user.ID)
+		assert.ErrorIs(t, err, repo.ErrUserNotFound)
+
+		entries, _, err := users.AuditLog(ctx, repo.AuditLogFilter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AuditUserDeleted, entries[0].Action)
+	})
+
+	t.Run("UnlockLogin should lift the lockout and record it", func(t *testing.T) {
+		users, _, user := setup(t)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, users.loginGuard.Failure(ctx, user.Username, "198.51.100.1"))
+		}
+		require.ErrorIs(t, users.loginGuard.Check(ctx, user.Username, ""), ErrLoginLocked)
+
+		require.NoError(t, users.UnlockLogin(ctx, admin, user.ID, ""))
+		assert.NoError(t, users.loginGuard.Check(ctx, user.Username, ""))
+
+		entries, _, err := users.AuditLog(ctx, repo.AuditLogFilter{SubjectID: &user.ID})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AuditUserLoginUnlocked, entries[0].Action)
+	})
+
+	t.Run("UnlockLogin with an IP should lift the lockout of the client IP too", func(t *testing.T) {
+		users, _, user := setup(t)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, users.loginGuard.Failure(ctx, fmt.Sprintf("agent%d", i), "198.51.100.1"))
+		}
+		require.ErrorIs(t, users.loginGuard.Check(ctx, "mittens", "198.51.100.1"), ErrLoginLocked)
+
+		require.NoError(t, users.UnlockLogin(ctx, admin, user.ID, "198.51.100.1"))
+		assert.NoError(t, users.loginGuard.Check(ctx, "mittens", "198.51.100.1"))
+
+		entries, _, err := users.AuditLog(ctx, repo.AuditLogFilter{SubjectID: &user.ID})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "ip 198.51.100.1", entries[0].Details)
+	})
+
+	t.Run("the audit trail should list the newest entries first", func(t *testing.T) {
+		users, _, user := setup(t)
+
+		require.NoError(t, users.RevokeTokens(ctx, admin, user.ID))
+		_, err := users.Invite(ctx, admin)
+		require.NoError(t, err)
+
+		entries, total, err := users.AuditLog(ctx, repo.AuditLogFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, AuditInvitationCreated, entries[0].Action)
+		assert.Nil(t, entries[0].SubjectID)
+		assert.Equal(t, AuditUserTokensRevoked, entries[1].Action)
+	})
+}
@@ -34,5 +34,6 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.Mission{},
 		&models.Target{},
 		&models.User{},
+		&models.AuditLog{},
 	)
 }
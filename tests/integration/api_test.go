@@ -5,10 +5,13 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"DevelopsToday/config"
 	"DevelopsToday/internal/app"
@@ -62,7 +65,8 @@ func setupTestApp(t *testing.T) (*gin.Engine, func()) {
 	require.NoError(t, err)
 
 	cleanup := func() {
-		// Clean up test data if needed
+		// Send the queued password reset emails before the database goes away
+		_ = application.Tasks.Close(context.Background())
 	}
 
 	return application.Handler, cleanup
@@ -111,6 +115,37 @@ func TestAuthIntegration(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
+
+	t.Run("Login should accept the email and ignore case", func(t *testing.T) {
+		for _, login := range []string{"Admin@SpyCats.com", " ADMIN "} {
+			body, _ := json.Marshal(map[string]string{
+				"username": login,
+				"password": testAdminPassword,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, login)
+		}
+	})
+
+	t.Run("Register should reject a username that differs only in case", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"username": "ADMIN",
+			"email":    fmt.Sprintf("admin_%d@spycats.com", time.Now().UnixNano()),
+			"password": "agent-password",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
 }
 
 func TestCatsIntegration(t *testing.T) {
@@ -129,10 +164,15 @@ func TestCatsIntegration(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var cats []models.Cat
-		err := json.Unmarshal(w.Body.Bytes(), &cats)
+		var response struct {
+			Data []models.Cat `json:"data"`
+			Meta struct {
+				Total int64 `json:"total"`
+			} `json:"meta"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
-		assert.IsType(t, []models.Cat{}, cats)
+		assert.IsType(t, []models.Cat{}, response.Data)
 	})
 
 	t.Run("POST /v1/cats should create new cat", func(t *testing.T) {
@@ -185,10 +225,12 @@ func TestMissionsIntegration(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var missions []models.Mission
-		err := json.Unmarshal(w.Body.Bytes(), &missions)
+		var response struct {
+			Data []models.Mission `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
-		assert.IsType(t, []models.Mission{}, missions)
+		assert.IsType(t, []models.Mission{}, response.Data)
 	})
 
 	t.Run("POST /v1/missions should create new mission", func(t *testing.T) {
@@ -220,6 +262,374 @@ func TestMissionsIntegration(t *testing.T) {
 	})
 }
 
+func TestSessionsIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	laptopToken := getAuthToken(t, router)
+	phoneToken := getAuthToken(t, router)
+
+	listSessions := func(t *testing.T, token string) []map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var sessions []map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+		return sessions
+	}
+
+	t.Run("GET /v1/auth/sessions should list both devices", func(t *testing.T) {
+		sessions := listSessions(t, laptopToken)
+		assert.Len(t, sessions, 2)
+	})
+
+	t.Run("DELETE /v1/auth/sessions/:sid should log out the other device only", func(t *testing.T) {
+		var phoneSession string
+		for _, session := range listSessions(t, phoneToken) {
+			if session["current"] == true {
+				phoneSession = session["id"].(string)
+			}
+		}
+		require.NotEmpty(t, phoneSession)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/sessions/"+phoneSession, nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		sessions := listSessions(t, laptopToken)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, true, sessions[0]["current"])
+	})
+
+	t.Run("DELETE /v1/auth/sessions/:sid with an unknown session should return 404", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/sessions/unknown", nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("DELETE /v1/auth/sessions should revoke the access token right away", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+laptopToken)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestLogoutIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	token := getAuthToken(t, router)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Run("the access token should stop working after logout", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAdminRevokeTokensIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	adminToken := getAuthToken(t, router)
+	userID, userToken := registerTestUser(t, router)
+	revokePath := fmt.Sprintf("/v1/admin/users/%d/revoke-tokens", userID)
+
+	t.Run("non-admins should not be able to revoke tokens", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, revokePath, nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("POST /v1/admin/users/:id/revoke-tokens should log the user out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, revokePath, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoking tokens of an unknown user should return 404", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/999999999/revoke-tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPermissionsIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	// Users that registered themselves have no write permissions
+	_, userToken := registerTestUser(t, router)
+
+	t.Run("POST /v1/cats without cats:write should return 403", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":       "Intruder",
+			"breed":      "Persian",
+			"experience": 1,
+			"salary":     1000,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/cats", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+userToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("GET /v1/cats should stay readable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/cats", nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAdminUsersIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	adminToken := getAuthToken(t, router)
+
+	t.Run("registration should ignore a requested role", func(t *testing.T) {
+		suffix := time.Now().UnixNano()
+		body, _ := json.Marshal(map[string]string{
+			"username": fmt.Sprintf("mallory_%d", suffix),
+			"email":    fmt.Sprintf("mallory_%d@spycats.com", suffix),
+			"password": "agent-password",
+			"role":     "admin",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			User struct {
+				Role string `json:"role"`
+			} `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "agent", response.User.Role)
+	})
+
+	t.Run("PUT /v1/admin/users/:id/role should change the role and record it", func(t *testing.T) {
+		userID, _ := registerTestUser(t, router)
+
+		body, _ := json.Marshal(map[string]string{"role": "manager"})
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/admin/users/%d/role", userID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/admin/audit-log?user_id=%d", userID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []models.AuditLog `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotEmpty(t, response.Data)
+		assert.Equal(t, "user.role_changed", response.Data[0].Action)
+	})
+
+	t.Run("assigning an unknown role should return 400", func(t *testing.T) {
+		userID, _ := registerTestUser(t, router)
+
+		body, _ := json.Marshal(map[string]string{"role": "superuser"})
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/admin/users/%d/role", userID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLoginLockoutIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	adminToken := getAuthToken(t, router)
+	userID, userToken := registerTestUser(t, router)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var me struct {
+		Username string `json:"username"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"username": me.Username, "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("repeated failures should be delayed with Retry-After", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("wrong-password").Code)
+		}
+
+		w := login("agent-password")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("POST /v1/admin/users/:id/unlock should allow logging in again", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/admin/users/%d/unlock", userID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusOK, login("agent-password").Code)
+	})
+}
+
+func TestPasswordIntegration(t *testing.T) {
+	router, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	t.Run("POST /v1/auth/password/change should revoke the current token", func(t *testing.T) {
+		_, userToken := registerTestUser(t, router)
+
+		post := func(body map[string]string) int {
+			data, _ := json.Marshal(body)
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/password/change", bytes.NewBuffer(data))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+userToken)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusForbidden, post(map[string]string{
+			"current_password": "wrong-password",
+			"new_password":     "changed-password",
+		}))
+
+		time.Sleep(5 * time.Millisecond)
+		require.Equal(t, http.StatusOK, post(map[string]string{
+			"current_password": "agent-password",
+			"new_password":     "changed-password",
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("POST /v1/auth/password/forgot should not reveal whether an email exists", func(t *testing.T) {
+		for _, email := range []string{"admin@spycats.com", "nobody@spycats.com"} {
+			body, _ := json.Marshal(map[string]string{"email": email})
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/password/forgot", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusAccepted, w.Code, email)
+		}
+	})
+
+	t.Run("POST /v1/auth/password/reset should reject unknown tokens", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"token": "made-up-token", "new_password": "changed-password"})
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/password/reset", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("POST /v1/auth/register should reject passwords that break the policy", func(t *testing.T) {
+		suffix := time.Now().UnixNano()
+		for _, password := range []string{"short1", "onlyletters", "password123"} {
+			body, _ := json.Marshal(map[string]string{
+				"username": fmt.Sprintf("agent_%d", suffix),
+				"email":    fmt.Sprintf("agent_%d@spycats.com", suffix),
+				"password": password,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, password)
+		}
+	})
+}
+
 func TestHealthCheck(t *testing.T) {
 	router, cleanup := setupTestApp(t)
 	defer cleanup()
@@ -265,3 +675,34 @@ func getAuthToken(t *testing.T, router *gin.Engine) string {
 
 	return token
 }
+
+// registerTestUser registers a user with a unique name and returns its ID and
+// access token
+func registerTestUser(t *testing.T, router *gin.Engine) (uint, string) {
+	suffix := time.Now().UnixNano()
+	registerData := map[string]string{
+		"username": fmt.Sprintf("agent_%d", suffix),
+		"email":    fmt.Sprintf("agent_%d@spycats.com", suffix),
+		"password": "agent-password",
+	}
+
+	body, _ := json.Marshal(registerData)
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		User struct {
+			ID uint `json:"id"`
+		} `json:"user"`
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(t, response.AccessToken)
+
+	return response.User.ID, response.AccessToken
+}